@@ -0,0 +1,511 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/veigaribo/qveen/qveen"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Name of the file with the generators and settings of a project. It
+// is looked for in the current directory and then in each of its
+// parents.
+const ConfigFileName = ".qveen.toml"
+
+type Generator struct {
+	Name        string
+	Description string
+
+	// Relative to the directory of the config, unless URLs.
+	Params []string
+
+	// Passed before the ones given to `qveen run`, which take
+	// precedence.
+	Flags []string
+}
+
+// How to fetch parameter files and templates given as URLs.
+type FetchConfig struct {
+	// 0 means no limit.
+	Timeout time.Duration
+	Retries int
+	MaxSize int64
+
+	// PEM file with certificates to trust besides those of the system.
+	// Relative to the directory of the config.
+	CaBundle string
+
+	// By host name, possibly with a port.
+	Hosts map[string]HostConfig
+}
+
+type HostConfig struct {
+	// Sent as a bearer token.
+	Token string
+
+	Headers map[string]string
+}
+
+// Used for whatever is not in the config, or if there is none.
+var DefaultFetchConfig = FetchConfig{
+	Timeout: 30 * time.Second,
+	Retries: 2,
+	MaxSize: 64 << 20,
+}
+
+type Config struct {
+	Path       string
+	Generators map[string]Generator
+	Fetch      FetchConfig
+}
+
+// The directory generators from this config run from.
+func (config *Config) Dir() string {
+	return filepath.Dir(config.Path)
+}
+
+// Sorted by name.
+func (config *Config) Names() []string {
+	names := make([]string, 0, len(config.Generators))
+
+	for name := range config.Generators {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Finds the closest config, starting from `dir` and going up. Nil if
+// there is none.
+func FindConfig(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		content, err := os.ReadFile(path)
+
+		if err == nil {
+			return ParseConfig(path, content)
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("Failed to read '%s': %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// Like `FindConfig`, but fails if there is none.
+func RequireConfig(dir string) (*Config, error) {
+	config, err := FindConfig(dir)
+
+	if err == nil && config == nil {
+		absDir, _ := filepath.Abs(dir)
+		err = fmt.Errorf("No %s found in '%s' or any of its parents", ConfigFileName, absDir)
+	}
+
+	return config, err
+}
+
+// Values in `fetch.hosts` may refer to environment variables as `$VAR`
+// or `${VAR}`, so that secrets need not be written in it.
+func ParseConfig(path string, content []byte) (*Config, error) {
+	var data struct {
+		Generators map[string]struct {
+			Description string   `toml:"description"`
+			Params      any      `toml:"params"`
+			Flags       []string `toml:"flags"`
+		} `toml:"generators"`
+
+		Fetch struct {
+			Timeout  *string `toml:"timeout"`
+			Retries  *int    `toml:"retries"`
+			MaxSize  *int64  `toml:"max_size"`
+			CaBundle string  `toml:"ca_bundle"`
+
+			Hosts map[string]struct {
+				Token   string            `toml:"token"`
+				Headers map[string]string `toml:"headers"`
+			} `toml:"hosts"`
+		} `toml:"fetch"`
+	}
+
+	err := toml.Unmarshal(content, &data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse '%s': %w", path, err)
+	}
+
+	config := Config{
+		Path:       path,
+		Generators: make(map[string]Generator, len(data.Generators)),
+		Fetch:      DefaultFetchConfig,
+	}
+
+	for name, entry := range data.Generators {
+		var paramsPaths []string
+
+		switch value := entry.Params.(type) {
+		case string:
+			paramsPaths = []string{value}
+		case []any:
+			for _, item := range value {
+				str, ok := item.(string)
+
+				if !ok {
+					paramsPaths = nil
+					break
+				}
+
+				paramsPaths = append(paramsPaths, str)
+			}
+		}
+
+		if len(paramsPaths) == 0 {
+			return nil, fmt.Errorf(
+				"Generator '%s' in '%s' must have `params` as a string or a list of strings",
+				name,
+				path,
+			)
+		}
+
+		config.Generators[name] = Generator{
+			Name:        name,
+			Description: entry.Description,
+			Params:      paramsPaths,
+			Flags:       entry.Flags,
+		}
+	}
+
+	fetch := &config.Fetch
+
+	if data.Fetch.Timeout != nil {
+		fetch.Timeout, err = time.ParseDuration(*data.Fetch.Timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid `fetch.timeout` in '%s': %w", path, err)
+		}
+	}
+
+	if data.Fetch.Retries != nil {
+		fetch.Retries = *data.Fetch.Retries
+	}
+
+	if data.Fetch.MaxSize != nil {
+		fetch.MaxSize = *data.Fetch.MaxSize
+	}
+
+	if fetch.Timeout < 0 || fetch.Retries < 0 || fetch.MaxSize < 0 {
+		return nil, fmt.Errorf("`fetch.timeout`, `fetch.retries` and `fetch.max_size` in '%s' must not be negative", path)
+	}
+
+	if data.Fetch.CaBundle != "" {
+		fetch.CaBundle = filepath.Join(config.Dir(), data.Fetch.CaBundle)
+	}
+
+	fetch.Hosts = make(map[string]HostConfig, len(data.Fetch.Hosts))
+
+	for host, entry := range data.Fetch.Hosts {
+		hostConfig := HostConfig{
+			Token:   os.ExpandEnv(entry.Token),
+			Headers: make(map[string]string, len(entry.Headers)),
+		}
+
+		for key, value := range entry.Headers {
+			hostConfig.Headers[key] = os.ExpandEnv(value)
+		}
+
+		fetch.Hosts[host] = hostConfig
+	}
+
+	return &config, nil
+}
+
+// A fetcher following `fetch`, caching in the default directory if
+// there is one.
+func NewFetcher(fetch FetchConfig, offline bool) (qveen.HTTPFetcher, error) {
+	client, err := qveen.NewHTTPClient(fetch.Timeout, fetch.CaBundle)
+
+	if err != nil {
+		return qveen.HTTPFetcher{}, err
+	}
+
+	fetcher := qveen.HTTPFetcher{
+		Client:  client,
+		Headers: make(map[string]http.Header, len(fetch.Hosts)),
+		Retries: fetch.Retries,
+		MaxSize: fetch.MaxSize,
+		Offline: offline,
+	}
+
+	for host, hostConfig := range fetch.Hosts {
+		headers := make(http.Header)
+
+		for key, value := range hostConfig.Headers {
+			headers.Set(key, value)
+		}
+
+		if hostConfig.Token != "" {
+			headers.Set("Authorization", "Bearer "+hostConfig.Token)
+		}
+
+		fetcher.Headers[host] = headers
+	}
+
+	cacheDir, err := qveen.DefaultCacheDir()
+
+	// Fetch every time otherwise.
+	if err == nil {
+		fetcher.Cache = &qveen.Cache{Dir: cacheDir}
+	}
+
+	return fetcher, nil
+}
+
+// Finds the config and sets up a fetcher following it only when
+// something is first fetched, so that a broken config in some parent
+// directory does not get in the way of renders that fetch nothing.
+type lazyFetcher struct {
+	offline bool
+
+	once    sync.Once
+	fetcher qveen.HTTPFetcher
+	err     error
+}
+
+func NewLazyFetcher(offline bool) qveen.PinnedFetcher {
+	return &lazyFetcher{offline: offline}
+}
+
+func (f *lazyFetcher) load() (qveen.HTTPFetcher, error) {
+	f.once.Do(func() {
+		fetch := DefaultFetchConfig
+		config, err := FindConfig(".")
+
+		if err != nil {
+			f.err = err
+			return
+		}
+
+		if config != nil {
+			fetch = config.Fetch
+		}
+
+		f.fetcher, f.err = NewFetcher(fetch, f.offline)
+	})
+
+	return f.fetcher, f.err
+}
+
+func (f *lazyFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	fetcher, err := f.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.Fetch(ctx, url)
+}
+
+func (f *lazyFetcher) FetchPinned(ctx context.Context, url string, sum string) (io.ReadCloser, error) {
+	fetcher, err := f.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.FetchPinned(ctx, url, sum)
+}
+
+// Writes the name and description of each generator, one per line.
+func ListGenerators(w io.Writer, config *Config) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, name := range config.Names() {
+		fmt.Fprintf(writer, "%s\t%s\n", name, config.Generators[name].Description)
+	}
+
+	return writer.Flush()
+}
+
+// Flags of `qveen run` holding paths, which are relative to where it is
+// run from rather than to the config.
+var runPathFlags = []string{"template", "output", "manifest", "report", "partials"}
+
+// Parses the arguments of `qveen run`, with the default flags of the
+// generator under those given, and moves to the directory of
+// the config. Returns the parameter files to render, or nil if only the
+// help was asked for.
+//
+// Expects flag parsing to be disabled for `cmd`, since the name must be
+// known before the defaults can be applied.
+func setupRun(cmd *cobra.Command, args []string) ([]string, error) {
+	// Parsed apart first, to find the name without touching the flags
+	// flags, which would get the values of the others twice.
+	given := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	given.Usage = func() {}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		given.AddFlag(scratchFlag(flag))
+	})
+
+	err := given.Parse(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	help, _ := given.GetBool("help")
+
+	if help {
+		return nil, cmd.Help()
+	}
+
+	if given.NArg() != 1 {
+		return nil, fmt.Errorf("Expected exactly one generator name, got %d", given.NArg())
+	}
+
+	name := given.Arg(0)
+	config, err := RequireConfig(".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	generator, ok := config.Generators[name]
+
+	if !ok {
+		return nil, fmt.Errorf(
+			"No generator named '%s' in '%s'. Available: %s",
+			name,
+			config.Path,
+			strings.Join(config.Names(), ", "),
+		)
+	}
+
+	// Parsing the given flags after the defaults makes them win, while
+	// key-value ones get merged.
+	flags := cmd.Flags()
+	err = flags.Parse(slices.Concat(generator.Flags, args))
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid flags for generator '%s' in '%s': %w", name, config.Path, err)
+	}
+
+	if flags.NArg() != 1 {
+		return nil, fmt.Errorf("Flags of generator '%s' in '%s' must not contain arguments", name, config.Path)
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, flagName := range runPathFlags {
+		err = absolutizeGiven(flags, given, flagName, cwd)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = os.Chdir(config.Dir())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return generator.Params, nil
+}
+
+// A flag like `flag`, of one of the types used by `registerFlag`, but
+// with a value of its own.
+func scratchFlag(flag *pflag.Flag) *pflag.Flag {
+	scratch := pflag.NewFlagSet("", pflag.ContinueOnError)
+
+	switch flag.Value.Type() {
+	case "bool":
+		scratch.BoolP(flag.Name, flag.Shorthand, false, "")
+	case "int":
+		scratch.IntP(flag.Name, flag.Shorthand, 0, "")
+	case "stringToString":
+		scratch.StringToStringP(flag.Name, flag.Shorthand, nil, "")
+	case "stringArray":
+		scratch.StringArrayP(flag.Name, flag.Shorthand, nil, "")
+	default:
+		scratch.StringP(flag.Name, flag.Shorthand, "", "")
+	}
+
+	return scratch.Lookup(flag.Name)
+}
+
+// Makes the local paths that were given as `name` in `given` absolute
+// from `cwd`, in `flags`, where they come after those of the generator.
+func absolutizeGiven(flags, given *pflag.FlagSet, name, cwd string) error {
+	givenFlag := given.Lookup(name)
+
+	if givenFlag == nil || !givenFlag.Changed {
+		return nil
+	}
+
+	flag := flags.Lookup(name)
+
+	if slice, ok := givenFlag.Value.(pflag.SliceValue); ok {
+		givenValues := slice.GetSlice()
+		values := flag.Value.(pflag.SliceValue).GetSlice()
+		defaults := values[:len(values)-len(givenValues)]
+
+		absolute := make([]string, 0, len(givenValues))
+
+		for _, value := range givenValues {
+			absolute = append(absolute, absolutePath(value, cwd))
+		}
+
+		return flag.Value.(pflag.SliceValue).Replace(slices.Concat(defaults, absolute))
+	}
+
+	return flag.Value.Set(absolutePath(givenFlag.Value.String(), cwd))
+}
+
+// Keeps the trailing separator of prefixes.
+func absolutePath(path, cwd string) string {
+	if path == "" || !utils.IsLocal(path) || filepath.IsAbs(path) {
+		return path
+	}
+
+	absolute := filepath.Join(cwd, path)
+
+	if utils.IsExplicitDir(path) {
+		absolute += string(filepath.Separator)
+	}
+
+	return absolute
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Setenv("QVEEN_TEST_TOKEN", "secret")
+
+	content := []byte(`
+[generators.service]
+description = "A service"
+params = "generators/service.toml"
+flags = ["-y"]
+
+[generators.layered]
+params = ["base.toml", "override.toml"]
+
+[fetch]
+timeout = "5s"
+retries = 0
+ca_bundle = "ca.pem"
+
+[fetch.hosts."example.com"]
+token = "$QVEEN_TEST_TOKEN"
+headers = { X-Team = "${QVEEN_TEST_TOKEN}-team" }
+`)
+
+	config, err := ParseConfig("/project/.qveen.toml", content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := config.Names(); !slices.Equal(names, []string{"layered", "service"}) {
+		t.Errorf("Expected the generators sorted by name, got %v", names)
+	}
+
+	service := config.Generators["service"]
+
+	if service.Description != "A service" ||
+		!slices.Equal(service.Params, []string{"generators/service.toml"}) ||
+		!slices.Equal(service.Flags, []string{"-y"}) {
+		t.Errorf("Unexpected service generator: %+v", service)
+	}
+
+	if params := config.Generators["layered"].Params; !slices.Equal(params, []string{"base.toml", "override.toml"}) {
+		t.Errorf("Expected both parameter files, in order, got %v", params)
+	}
+
+	fetch := config.Fetch
+
+	if fetch.Timeout != 5*time.Second || fetch.Retries != 0 || fetch.MaxSize != DefaultFetchConfig.MaxSize {
+		t.Errorf("Expected the given values over the defaults, got %+v", fetch)
+	}
+
+	if expected := filepath.Join("/project", "ca.pem"); fetch.CaBundle != expected {
+		t.Errorf("Expected the CA bundle at %s, got %s", expected, fetch.CaBundle)
+	}
+
+	host := fetch.Hosts["example.com"]
+
+	if host.Token != "secret" || host.Headers["X-Team"] != "secret-team" {
+		t.Errorf("Expected environment variables to be expanded, got %+v", host)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"syntax":          "[generators",
+		"missing params":  "[generators.a]\ndescription = \"A\"\n",
+		"invalid params":  "[generators.a]\nparams = [\"a.toml\", 1]\n",
+		"invalid timeout": "[fetch]\ntimeout = \"soon\"\n",
+		"negative":        "[fetch]\nretries = -1\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig(".qveen.toml", []byte(content))
+
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	err := os.MkdirAll(nested, 0755)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := FindConfig(nested)
+
+	if err != nil || config != nil {
+		t.Fatalf("Expected no config, got %v, %v", config, err)
+	}
+
+	path := filepath.Join(root, ConfigFileName)
+	err = os.WriteFile(path, []byte("[generators.a]\nparams = \"a.toml\"\n"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = FindConfig(nested)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config == nil || config.Path != path {
+		t.Fatalf("Expected the config at %s, got %v", path, config)
+	}
+}
+
+// Moves to `dir` until the test ends.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func TestSetupRun(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(root, "sub")
+	err = os.Mkdir(sub, 0755)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(root, ConfigFileName), []byte(`
+[generators.service]
+params = "service.toml"
+flags = ["-o", "default.txt", "-p", "a=1", "-p", "b=2", "-P", "lib.tmpl"]
+`), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output string
+	var promptValues map[string]string
+	var partials []string
+
+	cmd := &cobra.Command{Use: "run", DisableFlagParsing: true}
+	cmd.InitDefaultHelpFlag()
+
+	for _, flag := range []Flag{
+		{Type: StringFlagType, Short: "o", Long: "output", Target: &output},
+		{Type: StringToStringType, Short: "p", Long: "prompt-value", Target: &promptValues},
+		{Type: StringArrayFlagType, Short: "P", Long: "partials", Target: &partials},
+	} {
+		registerFlag(cmd, flag)
+	}
+
+	chdir(t, sub)
+	paramsPaths, err := setupRun(cmd, []string{"service", "-p", "b=3", "-P", "extra.tmpl"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(paramsPaths, []string{"service.toml"}) {
+		t.Errorf("Expected the parameter files of the generator, got %v", paramsPaths)
+	}
+
+	if cwd, _ := os.Getwd(); cwd != root {
+		t.Errorf("Expected to be in %s, got %s", root, cwd)
+	}
+
+	// Paths of the generator are relative to the config, and given ones
+	// to where `run` was called from.
+	if output != "default.txt" {
+		t.Errorf("Expected the output of the generator, got %s", output)
+	}
+
+	expectedPartials := []string{"lib.tmpl", filepath.Join(sub, "extra.tmpl")}
+
+	if !slices.Equal(partials, expectedPartials) {
+		t.Errorf("Expected partials %v, got %v", expectedPartials, partials)
+	}
+
+	expectedValues := map[string]string{"a": "1", "b": "3"}
+
+	if !maps.Equal(promptValues, expectedValues) {
+		t.Errorf("Expected prompt values %v, got %v", expectedValues, promptValues)
+	}
+}
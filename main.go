@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/veigaribo/qveen/qveen"
+	"github.com/veigaribo/qveen/utils"
 )
 
 func catchPanic() {
@@ -13,6 +19,7 @@ func catchPanic() {
 
 	if e != nil {
 		fmt.Fprintln(os.Stderr, e)
+		os.Exit(1)
 	}
 }
 
@@ -21,11 +28,13 @@ type FlagType uint
 const (
 	StringFlagType FlagType = iota
 	BoolFlagType
+	IntFlagType
 	StringToStringType
+	StringArrayFlagType
 )
 
 func (typ FlagType) AllowsMultiple() bool {
-	return typ == StringToStringType
+	return typ == StringToStringType || typ == StringArrayFlagType
 }
 
 type Flag struct {
@@ -54,37 +63,139 @@ func main() {
 	var leftDelimFlag string
 	var rightDelimFlag string
 	var caseFlag string
+	var manifestFlag string
+	var partialsFlag []string
+	var offlineFlag bool
 	var overwriteFlag bool
+	var dryRunFlag bool
+	var diffFlag bool
+	var watchFlag bool
+	var reportFlag string
+	var noHooksFlag bool
+	var jobsFlag int
+	var bundlePathFlag string
+
+	exitOnError := func(err error) {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(qveen.ExitCode(err))
+		}
+	}
+
+	makeOpts := func() qveen.Options {
+		return qveen.Options{
+			ParamsFormat: formatFlag,
+			TemplatePath: templatePathFlag,
+			OutputPath:   outputPathFlag,
+			MetaKey:      metaKeyFlag,
+			PromptValues: promptValueFlags,
+			Overwrite:    overwriteFlag,
+			DryRun:       dryRunFlag,
+			Diff:         diffFlag,
+			ManifestPath: manifestFlag,
+			Partials:     partialsFlag,
+			NoHooks:      noHooksFlag,
+			Jobs:         jobsFlag,
+
+			TemplateLeftDelim:  leftDelimFlag,
+			TemplateRightDelim: rightDelimFlag,
+			TemplateCase:       caseFlag,
+
+			Fetcher: NewLazyFetcher(offlineFlag),
+		}
+	}
+
+	renderOrWatch := func(ctx context.Context, paramsPaths []string) {
+		opts := makeOpts()
 
+		if watchFlag {
+			Watch(ctx, opts, paramsPaths, reportFlag)
+			return
+		}
+
+		exitOnError(render(ctx, qveen.NewRenderer(opts), paramsPaths, reportFlag))
+	}
+
+	// Same as `render`, which is kept implicit.
 	rootCmd := cobra.Command{
 		Use:   "qveen",
 		Short: "Generate files from templates.",
 		// TODO: Long description.
 
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MinimumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			renderOrWatch(cmd.Context(), args)
+		},
+	}
+
+	renderCmd := cobra.Command{
+		Use:   "render",
+		Short: "Generate files from templates.",
+
+		Args: cobra.MinimumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			renderOrWatch(cmd.Context(), args)
+		},
+	}
+
+	listCmd := cobra.Command{
+		Use:   "list",
+		Short: "Show the generators in the closest " + ConfigFileName + ".",
+
+		Args: cobra.NoArgs,
 
 		Run: func(cmd *cobra.Command, args []string) {
-			opts := RenderOptions{
-				ParamsPath:   args[0],
-				ParamsFormat: formatFlag,
-				TemplatePath: templatePathFlag,
-				OutputPath:   outputPathFlag,
-				MetaKey:      metaKeyFlag,
-				PromptValues: promptValueFlags,
-				Overwrite:    overwriteFlag,
-
-				TemplateLeftDelim:  leftDelimFlag,
-				TemplateRightDelim: rightDelimFlag,
-				TemplateCase:       caseFlag,
+			config, err := RequireConfig(".")
+			exitOnError(err)
+			exitOnError(ListGenerators(os.Stdout, config))
+		},
+	}
+
+	runCmd := cobra.Command{
+		Use:   "run",
+		Short: "Render a generator from the closest " + ConfigFileName + ", by name.",
+
+		// Done by `setupRun` once the defaults of the generator are known.
+		DisableFlagParsing: true,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			paramsPaths, err := setupRun(cmd, args)
+			exitOnError(err)
+
+			if paramsPaths != nil {
+				renderOrWatch(cmd.Context(), paramsPaths)
 			}
+		},
+	}
+
+	cleanCmd := cobra.Command{
+		Use:   "clean",
+		Short: "Delete generated files that would not be generated anymore.",
 
-			Render(opts)
+		Args: cobra.MinimumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			renderer := qveen.NewRenderer(makeOpts())
+			exitOnError(renderer.Clean(cmd.Context(), args...))
 		},
 	}
 
-	// We use this array to build with "usage" section when helping, and
-	// also as a base to actually register the flags with `cobra`.
-	flags := []Flag{
+	packCmd := cobra.Command{
+		Use:   "pack",
+		Short: "Bundle parameter files and everything they use into one archive.",
+
+		Args: cobra.MinimumNArgs(1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			exitOnError(Pack(cmd.Context(), qveen.NewRenderer(makeOpts()), args, bundlePathFlag))
+		},
+	}
+
+	// We use these arrays to build with "usage" section when helping,
+	// and also as a base to actually register the flags with `cobra`.
+	commonFlags := []Flag{
 		{
 			Type:          StringFlagType,
 			Short:         "t",
@@ -149,6 +260,40 @@ func main() {
 			Target:        &caseFlag,
 			Description:   "Will use the corresponding casing rules in templates if set.",
 		},
+		{
+			Type:          StringFlagType,
+			Short:         "M",
+			Long:          "manifest",
+			ParameterName: "manifest-file",
+			Target:        &manifestFlag,
+			Description:   "File in which to keep track of generated files, instead of `meta.manifest`.",
+		},
+		{
+			Type:          StringArrayFlagType,
+			Short:         "P",
+			Long:          "partials",
+			ParameterName: "file | glob | url",
+			Target:        &partialsFlag,
+			Description:   "Load template definitions from these files too, after those in `meta.partials`.",
+		},
+		{
+			Type:        BoolFlagType,
+			Short:       "O",
+			Long:        "offline",
+			Target:      &offlineFlag,
+			Description: "Do not fetch anything, and use only what was cached from earlier runs for URLs.",
+		},
+		{
+			Type:          IntFlagType,
+			Short:         "j",
+			Long:          "jobs",
+			ParameterName: "n",
+			Target:        &jobsFlag,
+			Description:   "Render up to this many pairs at the same time. One by default.",
+		},
+	}
+
+	rootFlags := slices.Concat(commonFlags, []Flag{
 		{
 			Type:        BoolFlagType,
 			Short:       "y",
@@ -156,10 +301,157 @@ func main() {
 			Target:      &overwriteFlag,
 			Description: "If set, won't ask for confirmation when overwriting files.",
 		},
+		{
+			Type:        BoolFlagType,
+			Short:       "n",
+			Long:        "dry-run",
+			Target:      &dryRunFlag,
+			Description: "Render everything and show what would be written, without touching any file.",
+		},
+		{
+			Type:        BoolFlagType,
+			Short:       "d",
+			Long:        "diff",
+			Target:      &diffFlag,
+			Description: "Show what would change in existing files before overwriting them. Identical files are skipped.",
+		},
+		{
+			Type:        BoolFlagType,
+			Short:       "w",
+			Long:        "watch",
+			Target:      &watchFlag,
+			Description: "Keep running and render again whenever the parameter files or templates change.",
+		},
+		{
+			Type:        BoolFlagType,
+			Short:       "N",
+			Long:        "no-hooks",
+			Target:      &noHooksFlag,
+			Description: "Do not run any of the commands in `meta.hooks` or `meta.pairs[].post`.",
+		},
+		{
+			Type:          StringFlagType,
+			Short:         "R",
+			Long:          "report",
+			ParameterName: "report-file | -",
+			Target:        &reportFlag,
+			Description:   "Write a JSON report of what was generated to a file, or to stdout if `-`.",
+		},
+	})
+
+	cleanFlags := slices.Concat(commonFlags, []Flag{
+		{
+			Type:        BoolFlagType,
+			Short:       "y",
+			Long:        "overwrite",
+			Target:      &overwriteFlag,
+			Description: "If set, won't ask for confirmation before deleting files.",
+		},
+		{
+			Type:        BoolFlagType,
+			Short:       "n",
+			Long:        "dry-run",
+			Target:      &dryRunFlag,
+			Description: "Only list the files that would be deleted.",
+		},
+	})
+
+	// Overriding templates or outputs would not carry over to the bundle.
+	packFlags := slices.Concat([]Flag{
+		{
+			Type:          StringFlagType,
+			Short:         "o",
+			Long:          "output",
+			ParameterName: "bundle-file",
+			Target:        &bundlePathFlag,
+			Description:   "Where to write the bundle, ending with .tar.gz, .tgz or .zip. Named after the parameter file by default.",
+		},
+	}, slices.DeleteFunc(slices.Clone(commonFlags), func(flag Flag) bool {
+		return flag.Long == "template" || flag.Long == "output" || flag.Long == "manifest"
+	}))
+
+	setupCommand(&rootCmd, rootFlags, "<params-file> ...")
+	setupCommand(&renderCmd, rootFlags, "<params-file> ...")
+	setupCommand(&cleanCmd, cleanFlags, "<params-file> ...")
+	setupCommand(&packCmd, packFlags, "<params-file> ...")
+	setupCommand(&listCmd, nil, "")
+	setupCommand(&runCmd, rootFlags, "<name>")
+
+	rootCmd.AddCommand(&renderCmd)
+	rootCmd.AddCommand(&cleanCmd)
+	rootCmd.AddCommand(&packCmd)
+	rootCmd.AddCommand(&listCmd)
+	rootCmd.AddCommand(&runCmd)
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	// Interruptions cancel the context instead of ending the process, so
+	// that files being written can be cleaned up. A second one ends it
+	// as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	if err != nil {
+		os.Exit(1)
 	}
+}
+
+// Writes the report to `reportPath`, if any, whether it fails or not.
+// It may be `-` for stdout.
+func render(
+	ctx context.Context,
+	renderer *qveen.Renderer,
+	paramsPaths []string,
+	reportPath string,
+) error {
+	report, err := renderer.Render(ctx, paramsPaths...)
+
+	if reportPath == "" {
+		return err
+	}
+
+	reportErr := writeReport(report, reportPath)
 
+	if reportErr != nil && err == nil {
+		return qveen.MakeRenderError(
+			qveen.IOErrorKind,
+			fmt.Errorf("Failed to write report: %w", reportErr),
+		)
+	}
+
+	return err
+}
+
+func writeReport(report qveen.Report, path string) error {
+	if utils.IsStd(path) {
+		return report.Write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = report.Write(file)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// `arguments` is how the positional arguments are shown in the usage.
+func setupCommand(cmd *cobra.Command, flags []Flag, arguments string) {
 	for _, flag := range flags {
-		registerFlag(&rootCmd, flag)
+		registerFlag(cmd, flag)
 	}
 
 	// Add information for `--help` so it shows up in the usage, but
@@ -173,13 +465,7 @@ func main() {
 		Description: "Show this message and exit.",
 	})
 
-	rootCmd.SetUsageFunc(usage(flags))
-
-	err := rootCmd.Execute()
-
-	if err != nil {
-		os.Exit(1)
-	}
+	cmd.SetUsageFunc(usage(flags, arguments))
 }
 
 func registerFlag(cmd *cobra.Command, flag Flag) {
@@ -204,6 +490,16 @@ func registerFlag(cmd *cobra.Command, flag Flag) {
 			false,
 			flag.Description,
 		)
+	case IntFlagType:
+		target := flag.Target.(*int)
+
+		cmd.Flags().IntVarP(
+			target,
+			flag.Long,
+			flag.Short,
+			0,
+			flag.Description,
+		)
 	case StringToStringType:
 		target := flag.Target.(*map[string]string)
 
@@ -214,10 +510,20 @@ func registerFlag(cmd *cobra.Command, flag Flag) {
 			make(map[string]string),
 			flag.Description,
 		)
+	case StringArrayFlagType:
+		target := flag.Target.(*[]string)
+
+		cmd.Flags().StringArrayVarP(
+			target,
+			flag.Long,
+			flag.Short,
+			nil,
+			flag.Description,
+		)
 	}
 }
 
-func usage(flags []Flag) func(cmd *cobra.Command) error {
+func usage(flags []Flag, arguments string) func(cmd *cobra.Command) error {
 	return func(cmd *cobra.Command) error {
 		// Not a hard limit. Will not break line if not in a good
 		// position to do so. A bit lower than usual to compensate.
@@ -244,7 +550,7 @@ func usage(flags []Flag) func(cmd *cobra.Command) error {
 		breakLine()
 		writeLine("  ")
 
-		writeLine(os.Args[0])
+		writeLine(cmd.CommandPath())
 		writeLine(" ")
 
 		// Column at which flags start.
@@ -295,7 +601,11 @@ func usage(flags []Flag) func(cmd *cobra.Command) error {
 			maybeBreakLine()
 		}
 
-		writeLine(" <params-file>")
+		if arguments != "" {
+			writeLine(" ")
+			writeLine(arguments)
+		}
+
 		breakLine()
 		breakLine()
 
@@ -352,6 +662,40 @@ func usage(flags []Flag) func(cmd *cobra.Command) error {
 			breakLine()
 		}
 
+		if cmd.HasAvailableSubCommands() {
+			breakLine()
+			writeLine("Commands:")
+			breakLine()
+
+			var commands []*cobra.Command
+			nameWidth := 0
+
+			for _, command := range cmd.Commands() {
+				if command.IsAvailableCommand() {
+					commands = append(commands, command)
+					nameWidth = max(nameWidth, len(command.Name()))
+				}
+			}
+
+			for _, command := range commands {
+				writeLine("  ")
+				writeLine(command.Name())
+
+				for rowLen < nameWidth+5 {
+					writeLine(" ")
+				}
+
+				writeLine(command.Short)
+				breakLine()
+			}
+
+			breakLine()
+			writeLine("Run `")
+			writeLine(cmd.CommandPath())
+			writeLine(" <command> --help` for their options.")
+			breakLine()
+		}
+
 		breakLine()
 		fmt.Fprint(cmd.OutOrStderr(), builder.String())
 		return nil
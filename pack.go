@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/veigaribo/qveen/qveen"
+)
+
+// Writes the bundle to `bundlePath`, or to one named after the first
+// parameter file in the current directory if empty.
+func Pack(
+	ctx context.Context,
+	renderer *qveen.Renderer,
+	paramsPaths []string,
+	bundlePath string,
+) error {
+	if bundlePath == "" {
+		base := filepath.Base(paramsPaths[0])
+		bundlePath = strings.TrimSuffix(base, filepath.Ext(base)) + ".tar.gz"
+	}
+
+	format := qveen.BundleFormatFor(bundlePath)
+
+	if format == "" {
+		return fmt.Errorf("Cannot tell the format of '%s'. Expected it to end with .tar.gz, .tgz or .zip.", bundlePath)
+	}
+
+	var content bytes.Buffer
+	manifest, err := renderer.Pack(ctx, &content, format, paramsPaths...)
+
+	if err != nil {
+		return err
+	}
+
+	err = qveen.DiskFS{}.WriteFiles(ctx, []qveen.OutputFile{{
+		Path:    bundlePath,
+		Content: content.Bytes(),
+	}})
+
+	if err != nil {
+		return qveen.MakeRenderError(
+			qveen.IOErrorKind,
+			fmt.Errorf("Failed to write bundle: %w", err),
+		)
+	}
+
+	for _, file := range manifest.Files {
+		fmt.Fprintln(os.Stderr, file)
+	}
+
+	fmt.Fprintf(os.Stderr, "Packed %d files into '%s'.\n", len(manifest.Files), bundlePath)
+	return nil
+}
@@ -1,6 +1,6 @@
 package params
 
-// This file has been generated by Qveen from `qveen/params/errors.yaml`.
+// This file has been generated by Qveen from `params/errors.yaml`.
 // Please do not modify it directly.
 
 import (
@@ -78,6 +78,258 @@ func (e MetaCaseWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
+type MetaHooksWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksWrongTypeError(path []any) MetaHooksWrongTypeError {
+	return MetaHooksWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a table.",
+		),
+	}
+}
+
+func (e MetaHooksWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksDirWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksDirWrongTypeError(path []any) MetaHooksDirWrongTypeError {
+	return MetaHooksDirWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but contains neither a string nor a table.",
+		),
+	}
+}
+
+func (e MetaHooksDirWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksDirWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksDirFromWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksDirFromWrongTypeError(path []any) MetaHooksDirFromWrongTypeError {
+	return MetaHooksDirFromWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaHooksDirFromWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksDirFromWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksDirFromInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksDirFromInvalidError(path []any) MetaHooksDirFromInvalidError {
+	return MetaHooksDirFromInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
+		),
+	}
+}
+
+func (e MetaHooksDirFromInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksDirFromInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksDirPathWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksDirPathWrongTypeError(path []any) MetaHooksDirPathWrongTypeError {
+	return MetaHooksDirPathWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaHooksDirPathWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksDirPathWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksDirPathMissingError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksDirPathMissingError(path []any) MetaHooksDirPathMissingError {
+	return MetaHooksDirPathMissingError{
+		Err: MakeParamError(
+			path,
+			"missing required field.",
+		),
+	}
+}
+
+func (e MetaHooksDirPathMissingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksDirPathMissingError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksEnvWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksEnvWrongTypeError(path []any) MetaHooksEnvWrongTypeError {
+	return MetaHooksEnvWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a table.",
+		),
+	}
+}
+
+func (e MetaHooksEnvWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksEnvWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksEnvValueWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksEnvValueWrongTypeError(path []any) MetaHooksEnvValueWrongTypeError {
+	return MetaHooksEnvValueWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaHooksEnvValueWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksEnvValueWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksPostWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksPostWrongTypeError(path []any) MetaHooksPostWrongTypeError {
+	return MetaHooksPostWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain an array.",
+		),
+	}
+}
+
+func (e MetaHooksPostWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksPostWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksPostCommandWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksPostCommandWrongTypeError(path []any) MetaHooksPostCommandWrongTypeError {
+	return MetaHooksPostCommandWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaHooksPostCommandWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksPostCommandWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksPreWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksPreWrongTypeError(path []any) MetaHooksPreWrongTypeError {
+	return MetaHooksPreWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain an array.",
+		),
+	}
+}
+
+func (e MetaHooksPreWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksPreWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaHooksPreCommandWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaHooksPreCommandWrongTypeError(path []any) MetaHooksPreCommandWrongTypeError {
+	return MetaHooksPreCommandWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaHooksPreCommandWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaHooksPreCommandWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
 type MetaLeftDelimWrongTypeError struct {
 	Err ParamError
 }
@@ -99,75 +351,705 @@ func (e MetaLeftDelimWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairWrongTypeError struct {
+type MetaManifestWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairWrongTypeError(path []any) MetaPairWrongTypeError {
-	return MetaPairWrongTypeError{
+func MakeMetaManifestWrongTypeError(path []any) MetaManifestWrongTypeError {
+	return MetaManifestWrongTypeError{
 		Err: MakeParamError(
 			path,
-			"field present but does not contain a table.",
+			"field present but contains neither a string nor a table.",
 		),
 	}
 }
 
-func (e MetaPairWrongTypeError) Error() string {
+func (e MetaManifestWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairWrongTypeError) Unwrap() error {
+func (e MetaManifestWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputWrongTypeError struct {
+type MetaManifestFromWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputWrongTypeError(path []any) MetaPairOutputWrongTypeError {
-	return MetaPairOutputWrongTypeError{
+func MakeMetaManifestFromWrongTypeError(path []any) MetaManifestFromWrongTypeError {
+	return MetaManifestFromWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaManifestFromWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaManifestFromWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaManifestFromInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaManifestFromInvalidError(path []any) MetaManifestFromInvalidError {
+	return MetaManifestFromInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
+		),
+	}
+}
+
+func (e MetaManifestFromInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaManifestFromInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaManifestPathWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaManifestPathWrongTypeError(path []any) MetaManifestPathWrongTypeError {
+	return MetaManifestPathWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaManifestPathWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaManifestPathWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaManifestPathMissingError struct {
+	Err ParamError
+}
+
+func MakeMetaManifestPathMissingError(path []any) MetaManifestPathMissingError {
+	return MetaManifestPathMissingError{
+		Err: MakeParamError(
+			path,
+			"missing required field.",
+		),
+	}
+}
+
+func (e MetaManifestPathMissingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaManifestPathMissingError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairWrongTypeError(path []any) MetaPairWrongTypeError {
+	return MetaPairWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a table.",
+		),
+	}
+}
+
+func (e MetaPairWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairForeachWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairForeachWrongTypeError(path []any) MetaPairForeachWrongTypeError {
+	return MetaPairForeachWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairForeachWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairForeachWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairFormatWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairFormatWrongTypeError(path []any) MetaPairFormatWrongTypeError {
+	return MetaPairFormatWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairFormatWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairFormatWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairFormatInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaPairFormatInvalidError(path []any) MetaPairFormatInvalidError {
+	return MetaPairFormatInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", OutputFormats),
+		),
+	}
+}
+
+func (e MetaPairFormatInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairFormatInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairIfExistsWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairIfExistsWrongTypeError(path []any) MetaPairIfExistsWrongTypeError {
+	return MetaPairIfExistsWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairIfExistsWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairIfExistsWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairIfExistsInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaPairIfExistsInvalidError(path []any) MetaPairIfExistsInvalidError {
+	return MetaPairIfExistsInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", IfExistsPolicies),
+		),
+	}
+}
+
+func (e MetaPairIfExistsInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairIfExistsInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectWrongTypeError(path []any) MetaPairInjectWrongTypeError {
+	return MetaPairInjectWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a table.",
+		),
+	}
+}
+
+func (e MetaPairInjectWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectAfterWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectAfterWrongTypeError(path []any) MetaPairInjectAfterWrongTypeError {
+	return MetaPairInjectAfterWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairInjectAfterWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectAfterWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectAtWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectAtWrongTypeError(path []any) MetaPairInjectAtWrongTypeError {
+	return MetaPairInjectAtWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairInjectAtWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectAtWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectAtInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectAtInvalidError(path []any) MetaPairInjectAtInvalidError {
+	return MetaPairInjectAtInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"top", "bottom"}),
+		),
+	}
+}
+
+func (e MetaPairInjectAtInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectAtInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectBeforeWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectBeforeWrongTypeError(path []any) MetaPairInjectBeforeWrongTypeError {
+	return MetaPairInjectBeforeWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairInjectBeforeWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectBeforeWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairInjectSkipIfWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectSkipIfWrongTypeError(path []any) MetaPairInjectSkipIfWrongTypeError {
+	return MetaPairInjectSkipIfWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairInjectSkipIfWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectSkipIfWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairModeWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairModeWrongTypeError(path []any) MetaPairModeWrongTypeError {
+	return MetaPairModeWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairModeWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairModeWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputWrongTypeError(path []any) MetaPairOutputWrongTypeError {
+	return MetaPairOutputWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but contains neither a string nor a table.",
+		),
+	}
+}
+
+func (e MetaPairOutputWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputMissingError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputMissingError(path []any) MetaPairOutputMissingError {
+	return MetaPairOutputMissingError{
+		Err: MakeParamError(
+			path,
+			"missing required field.",
+		),
+	}
+}
+
+func (e MetaPairOutputMissingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputMissingError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputFromWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputFromWrongTypeError(path []any) MetaPairOutputFromWrongTypeError {
+	return MetaPairOutputFromWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairOutputFromWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputFromWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputFromInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputFromInvalidError(path []any) MetaPairOutputFromInvalidError {
+	return MetaPairOutputFromInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
+		),
+	}
+}
+
+func (e MetaPairOutputFromInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputFromInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputPathWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputPathWrongTypeError(path []any) MetaPairOutputPathWrongTypeError {
+	return MetaPairOutputPathWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairOutputPathWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputPathWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairOutputPathMissingError struct {
+	Err ParamError
+}
+
+func MakeMetaPairOutputPathMissingError(path []any) MetaPairOutputPathMissingError {
+	return MetaPairOutputPathMissingError{
+		Err: MakeParamError(
+			path,
+			"missing required field.",
+		),
+	}
+}
+
+func (e MetaPairOutputPathMissingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairOutputPathMissingError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairPostWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairPostWrongTypeError(path []any) MetaPairPostWrongTypeError {
+	return MetaPairPostWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain an array.",
+		),
+	}
+}
+
+func (e MetaPairPostWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairPostWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairPostCommandWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairPostCommandWrongTypeError(path []any) MetaPairPostCommandWrongTypeError {
+	return MetaPairPostCommandWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairPostCommandWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairPostCommandWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairPreserveModeWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairPreserveModeWrongTypeError(path []any) MetaPairPreserveModeWrongTypeError {
+	return MetaPairPreserveModeWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a boolean.",
+		),
+	}
+}
+
+func (e MetaPairPreserveModeWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairPreserveModeWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateWrongTypeError(path []any) MetaPairTemplateWrongTypeError {
+	return MetaPairTemplateWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but contains neither a string nor a table.",
+		),
+	}
+}
+
+func (e MetaPairTemplateWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateMissingError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateMissingError(path []any) MetaPairTemplateMissingError {
+	return MetaPairTemplateMissingError{
+		Err: MakeParamError(
+			path,
+			"missing required field.",
+		),
+	}
+}
+
+func (e MetaPairTemplateMissingError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateMissingError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateFromWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateFromWrongTypeError(path []any) MetaPairTemplateFromWrongTypeError {
+	return MetaPairTemplateFromWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaPairTemplateFromWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateFromWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateFromInvalidError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateFromInvalidError(path []any) MetaPairTemplateFromInvalidError {
+	return MetaPairTemplateFromInvalidError{
+		Err: MakeParamError(
+			path,
+			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
+		),
+	}
+}
+
+func (e MetaPairTemplateFromInvalidError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateFromInvalidError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplatePathWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplatePathWrongTypeError(path []any) MetaPairTemplatePathWrongTypeError {
+	return MetaPairTemplatePathWrongTypeError{
 		Err: MakeParamError(
 			path,
-			"field present but contains neither a string nor a table.",
+			"field present but does not contain a string.",
 		),
 	}
 }
 
-func (e MetaPairOutputWrongTypeError) Error() string {
+func (e MetaPairTemplatePathWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputWrongTypeError) Unwrap() error {
+func (e MetaPairTemplatePathWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputMissingError struct {
+type MetaPairTemplatePathMissingError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputMissingError(path []any) MetaPairOutputMissingError {
-	return MetaPairOutputMissingError{
+func MakeMetaPairTemplatePathMissingError(path []any) MetaPairTemplatePathMissingError {
+	return MetaPairTemplatePathMissingError{
 		Err: MakeParamError(
 			path,
-			"missing required field.",
+			"missing required field. May be `url` instead.",
 		),
 	}
 }
 
-func (e MetaPairOutputMissingError) Error() string {
+func (e MetaPairTemplatePathMissingError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputMissingError) Unwrap() error {
+func (e MetaPairTemplatePathMissingError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputFromWrongTypeError struct {
+type MetaPairTemplateSha256WrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputFromWrongTypeError(path []any) MetaPairOutputFromWrongTypeError {
-	return MetaPairOutputFromWrongTypeError{
+func MakeMetaPairTemplateSha256WrongTypeError(path []any) MetaPairTemplateSha256WrongTypeError {
+	return MetaPairTemplateSha256WrongTypeError{
 		Err: MakeParamError(
 			path,
 			"field present but does not contain a string.",
@@ -175,41 +1057,41 @@ func MakeMetaPairOutputFromWrongTypeError(path []any) MetaPairOutputFromWrongTyp
 	}
 }
 
-func (e MetaPairOutputFromWrongTypeError) Error() string {
+func (e MetaPairTemplateSha256WrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputFromWrongTypeError) Unwrap() error {
+func (e MetaPairTemplateSha256WrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputFromInvalidError struct {
+type MetaPairTemplateSuffixWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputFromInvalidError(path []any) MetaPairOutputFromInvalidError {
-	return MetaPairOutputFromInvalidError{
+func MakeMetaPairTemplateSuffixWrongTypeError(path []any) MetaPairTemplateSuffixWrongTypeError {
+	return MetaPairTemplateSuffixWrongTypeError{
 		Err: MakeParamError(
 			path,
-			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
+			"field present but does not contain a string.",
 		),
 	}
 }
 
-func (e MetaPairOutputFromInvalidError) Error() string {
+func (e MetaPairTemplateSuffixWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputFromInvalidError) Unwrap() error {
+func (e MetaPairTemplateSuffixWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputPathWrongTypeError struct {
+type MetaPairTemplateUrlWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputPathWrongTypeError(path []any) MetaPairOutputPathWrongTypeError {
-	return MetaPairOutputPathWrongTypeError{
+func MakeMetaPairTemplateUrlWrongTypeError(path []any) MetaPairTemplateUrlWrongTypeError {
+	return MetaPairTemplateUrlWrongTypeError{
 		Err: MakeParamError(
 			path,
 			"field present but does not contain a string.",
@@ -217,83 +1099,83 @@ func MakeMetaPairOutputPathWrongTypeError(path []any) MetaPairOutputPathWrongTyp
 	}
 }
 
-func (e MetaPairOutputPathWrongTypeError) Error() string {
+func (e MetaPairTemplateUrlWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputPathWrongTypeError) Unwrap() error {
+func (e MetaPairTemplateUrlWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairOutputPathMissingError struct {
+type MetaPairWhenWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairOutputPathMissingError(path []any) MetaPairOutputPathMissingError {
-	return MetaPairOutputPathMissingError{
+func MakeMetaPairWhenWrongTypeError(path []any) MetaPairWhenWrongTypeError {
+	return MetaPairWhenWrongTypeError{
 		Err: MakeParamError(
 			path,
-			"missing required field.",
+			"field present but contains neither a string nor a boolean.",
 		),
 	}
 }
 
-func (e MetaPairOutputPathMissingError) Error() string {
+func (e MetaPairWhenWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairOutputPathMissingError) Unwrap() error {
+func (e MetaPairWhenWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplateWrongTypeError struct {
+type MetaPairsWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplateWrongTypeError(path []any) MetaPairTemplateWrongTypeError {
-	return MetaPairTemplateWrongTypeError{
+func MakeMetaPairsWrongTypeError(path []any) MetaPairsWrongTypeError {
+	return MetaPairsWrongTypeError{
 		Err: MakeParamError(
 			path,
-			"field present but contains neither a string nor a table.",
+			"field present but does not contain an array.",
 		),
 	}
 }
 
-func (e MetaPairTemplateWrongTypeError) Error() string {
+func (e MetaPairsWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplateWrongTypeError) Unwrap() error {
+func (e MetaPairsWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplateMissingError struct {
+type MetaPartialWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplateMissingError(path []any) MetaPairTemplateMissingError {
-	return MetaPairTemplateMissingError{
+func MakeMetaPartialWrongTypeError(path []any) MetaPartialWrongTypeError {
+	return MetaPartialWrongTypeError{
 		Err: MakeParamError(
 			path,
-			"missing required field.",
+			"field present but contains neither a string nor a table.",
 		),
 	}
 }
 
-func (e MetaPairTemplateMissingError) Error() string {
+func (e MetaPartialWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplateMissingError) Unwrap() error {
+func (e MetaPartialWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplateFromWrongTypeError struct {
+type MetaPartialFromWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplateFromWrongTypeError(path []any) MetaPairTemplateFromWrongTypeError {
-	return MetaPairTemplateFromWrongTypeError{
+func MakeMetaPartialFromWrongTypeError(path []any) MetaPartialFromWrongTypeError {
+	return MetaPartialFromWrongTypeError{
 		Err: MakeParamError(
 			path,
 			"field present but does not contain a string.",
@@ -301,20 +1183,20 @@ func MakeMetaPairTemplateFromWrongTypeError(path []any) MetaPairTemplateFromWron
 	}
 }
 
-func (e MetaPairTemplateFromWrongTypeError) Error() string {
+func (e MetaPartialFromWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplateFromWrongTypeError) Unwrap() error {
+func (e MetaPartialFromWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplateFromInvalidError struct {
+type MetaPartialFromInvalidError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplateFromInvalidError(path []any) MetaPairTemplateFromInvalidError {
-	return MetaPairTemplateFromInvalidError{
+func MakeMetaPartialFromInvalidError(path []any) MetaPartialFromInvalidError {
+	return MetaPartialFromInvalidError{
 		Err: MakeParamError(
 			path,
 			fmt.Sprintf("field does not contain one of the allowed values: %v.", []string{"params", "cwd"}),
@@ -322,20 +1204,20 @@ func MakeMetaPairTemplateFromInvalidError(path []any) MetaPairTemplateFromInvali
 	}
 }
 
-func (e MetaPairTemplateFromInvalidError) Error() string {
+func (e MetaPartialFromInvalidError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplateFromInvalidError) Unwrap() error {
+func (e MetaPartialFromInvalidError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplatePathWrongTypeError struct {
+type MetaPartialPathWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplatePathWrongTypeError(path []any) MetaPairTemplatePathWrongTypeError {
-	return MetaPairTemplatePathWrongTypeError{
+func MakeMetaPartialPathWrongTypeError(path []any) MetaPartialPathWrongTypeError {
+	return MetaPartialPathWrongTypeError{
 		Err: MakeParamError(
 			path,
 			"field present but does not contain a string.",
@@ -343,20 +1225,20 @@ func MakeMetaPairTemplatePathWrongTypeError(path []any) MetaPairTemplatePathWron
 	}
 }
 
-func (e MetaPairTemplatePathWrongTypeError) Error() string {
+func (e MetaPartialPathWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplatePathWrongTypeError) Unwrap() error {
+func (e MetaPartialPathWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairTemplatePathMissingError struct {
+type MetaPartialPathMissingError struct {
 	Err ParamError
 }
 
-func MakeMetaPairTemplatePathMissingError(path []any) MetaPairTemplatePathMissingError {
-	return MetaPairTemplatePathMissingError{
+func MakeMetaPartialPathMissingError(path []any) MetaPartialPathMissingError {
+	return MetaPartialPathMissingError{
 		Err: MakeParamError(
 			path,
 			"missing required field.",
@@ -364,20 +1246,20 @@ func MakeMetaPairTemplatePathMissingError(path []any) MetaPairTemplatePathMissin
 	}
 }
 
-func (e MetaPairTemplatePathMissingError) Error() string {
+func (e MetaPartialPathMissingError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairTemplatePathMissingError) Unwrap() error {
+func (e MetaPartialPathMissingError) Unwrap() error {
 	return e.Err
 }
 
-type MetaPairsWrongTypeError struct {
+type MetaPartialsWrongTypeError struct {
 	Err ParamError
 }
 
-func MakeMetaPairsWrongTypeError(path []any) MetaPairsWrongTypeError {
-	return MetaPairsWrongTypeError{
+func MakeMetaPartialsWrongTypeError(path []any) MetaPartialsWrongTypeError {
+	return MetaPartialsWrongTypeError{
 		Err: MakeParamError(
 			path,
 			"field present but does not contain an array.",
@@ -385,11 +1267,11 @@ func MakeMetaPairsWrongTypeError(path []any) MetaPairsWrongTypeError {
 	}
 }
 
-func (e MetaPairsWrongTypeError) Error() string {
+func (e MetaPartialsWrongTypeError) Error() string {
 	return e.Err.Error()
 }
 
-func (e MetaPairsWrongTypeError) Unwrap() error {
+func (e MetaPartialsWrongTypeError) Unwrap() error {
 	return e.Err
 }
 
@@ -863,7 +1745,7 @@ func MakeMetaRootTemplatePathMissingError(path []any) MetaRootTemplatePathMissin
 	return MetaRootTemplatePathMissingError{
 		Err: MakeParamError(
 			path,
-			"missing required field.",
+			"missing required field. May be `url` instead.",
 		),
 	}
 }
@@ -876,6 +1758,69 @@ func (e MetaRootTemplatePathMissingError) Unwrap() error {
 	return e.Err
 }
 
+type MetaRootTemplateSha256WrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateSha256WrongTypeError(path []any) MetaRootTemplateSha256WrongTypeError {
+	return MetaRootTemplateSha256WrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaRootTemplateSha256WrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateSha256WrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaRootTemplateSuffixWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateSuffixWrongTypeError(path []any) MetaRootTemplateSuffixWrongTypeError {
+	return MetaRootTemplateSuffixWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaRootTemplateSuffixWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateSuffixWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
+type MetaRootTemplateUrlWrongTypeError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateUrlWrongTypeError(path []any) MetaRootTemplateUrlWrongTypeError {
+	return MetaRootTemplateUrlWrongTypeError{
+		Err: MakeParamError(
+			path,
+			"field present but does not contain a string.",
+		),
+	}
+}
+
+func (e MetaRootTemplateUrlWrongTypeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateUrlWrongTypeError) Unwrap() error {
+	return e.Err
+}
+
 type MetaRootTemplateMissingInMultipleError struct {
 	Err ParamError
 }
@@ -917,3 +1862,171 @@ func (e MetaRootOutputMissingInMultipleError) Error() string {
 func (e MetaRootOutputMissingInMultipleError) Unwrap() error {
 	return e.Err
 }
+
+type MetaPairInjectAnchorConflictError struct {
+	Err ParamError
+}
+
+func MakeMetaPairInjectAnchorConflictError(path []any) MetaPairInjectAnchorConflictError {
+	return MetaPairInjectAnchorConflictError{
+		Err: MakeParamError(
+			path,
+			"only one of `before`, `after` and `at` may be set.",
+		),
+	}
+}
+
+func (e MetaPairInjectAnchorConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairInjectAnchorConflictError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairModeIntegerError struct {
+	Err ParamError
+}
+
+func MakeMetaPairModeIntegerError(path []any) MetaPairModeIntegerError {
+	return MetaPairModeIntegerError{
+		Err: MakeParamError(
+			path,
+			"field contains an integer, which is not read as octal. Quote it instead, as in `mode = \"755\"`.",
+		),
+	}
+}
+
+func (e MetaPairModeIntegerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairModeIntegerError) Unwrap() error {
+	return e.Err
+}
+
+type MetaRootTemplateUrlConflictError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateUrlConflictError(path []any) MetaRootTemplateUrlConflictError {
+	return MetaRootTemplateUrlConflictError{
+		Err: MakeParamError(
+			path,
+			"only one of `path` and `url` may be set.",
+		),
+	}
+}
+
+func (e MetaRootTemplateUrlConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateUrlConflictError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateUrlConflictError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateUrlConflictError(path []any) MetaPairTemplateUrlConflictError {
+	return MetaPairTemplateUrlConflictError{
+		Err: MakeParamError(
+			path,
+			"only one of `path` and `url` may be set.",
+		),
+	}
+}
+
+func (e MetaPairTemplateUrlConflictError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateUrlConflictError) Unwrap() error {
+	return e.Err
+}
+
+type MetaRootTemplateUrlNotUrlError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateUrlNotUrlError(path []any) MetaRootTemplateUrlNotUrlError {
+	return MetaRootTemplateUrlNotUrlError{
+		Err: MakeParamError(
+			path,
+			"field does not contain an URL.",
+		),
+	}
+}
+
+func (e MetaRootTemplateUrlNotUrlError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateUrlNotUrlError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateUrlNotUrlError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateUrlNotUrlError(path []any) MetaPairTemplateUrlNotUrlError {
+	return MetaPairTemplateUrlNotUrlError{
+		Err: MakeParamError(
+			path,
+			"field does not contain an URL.",
+		),
+	}
+}
+
+func (e MetaPairTemplateUrlNotUrlError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateUrlNotUrlError) Unwrap() error {
+	return e.Err
+}
+
+type MetaRootTemplateSha256NotHashError struct {
+	Err ParamError
+}
+
+func MakeMetaRootTemplateSha256NotHashError(path []any) MetaRootTemplateSha256NotHashError {
+	return MetaRootTemplateSha256NotHashError{
+		Err: MakeParamError(
+			path,
+			"field does not contain a SHA-256 hash as 64 hexadecimal digits.",
+		),
+	}
+}
+
+func (e MetaRootTemplateSha256NotHashError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaRootTemplateSha256NotHashError) Unwrap() error {
+	return e.Err
+}
+
+type MetaPairTemplateSha256NotHashError struct {
+	Err ParamError
+}
+
+func MakeMetaPairTemplateSha256NotHashError(path []any) MetaPairTemplateSha256NotHashError {
+	return MetaPairTemplateSha256NotHashError{
+		Err: MakeParamError(
+			path,
+			"field does not contain a SHA-256 hash as 64 hexadecimal digits.",
+		),
+	}
+}
+
+func (e MetaPairTemplateSha256NotHashError) Error() string {
+	return e.Err.Error()
+}
+
+func (e MetaPairTemplateSha256NotHashError) Unwrap() error {
+	return e.Err
+}
@@ -7,7 +7,10 @@ import (
 
 // Prompt param expansion must be done earlier to display the correct
 // prompts...
-func (p *Params) ExpandPromptParams(metaKey string) error {
+func (p *Params) ExpandPromptParams(
+	engine *templates.Engine,
+	metaKey string,
+) error {
 	var err error
 
 	for i := range p.Prompt {
@@ -19,7 +22,7 @@ func (p *Params) ExpandPromptParams(metaKey string) error {
 			)
 		}
 
-		entry.Name, err = templates.ExpandString(
+		entry.Name, err = engine.ExpandString(
 			templateName("name"),
 			entry.Name,
 			p.Data,
@@ -29,7 +32,7 @@ func (p *Params) ExpandPromptParams(metaKey string) error {
 			return err
 		}
 
-		entry.Title, err = templates.ExpandString(
+		entry.Title, err = engine.ExpandString(
 			templateName("title"),
 			entry.Title,
 			p.Data,
@@ -77,12 +80,13 @@ func (c ContainerPtr) Set(value any) {
 
 // Recursively expands strings.
 func expandParamsVisit(
+	engine *templates.Engine,
 	data map[string]any,
 	ptr ContainerPtr,
 	value any,
 ) error {
 	if str, ok := value.(string); ok {
-		expanded, err := templates.ExpandString(
+		expanded, err := engine.ExpandString(
 			utils.PathString(append(ptr.Path, ptr.Key)),
 			str,
 			data,
@@ -96,12 +100,12 @@ func expandParamsVisit(
 	} else if m, ok := value.(map[string]any); ok {
 		for k, v := range m {
 			ptr := MakeContainerPtr(m, k, append(ptr.Path, k))
-			return expandParamsVisit(data, ptr, v)
+			return expandParamsVisit(engine, data, ptr, v)
 		}
 	} else if s, ok := value.([]any); ok {
 		for i, v := range s {
 			ptr := MakeContainerPtr(s, i, append(ptr.Path, i))
-			return expandParamsVisit(data, ptr, v)
+			return expandParamsVisit(engine, data, ptr, v)
 		}
 	}
 
@@ -110,14 +114,17 @@ func expandParamsVisit(
 
 // ...other params must be expanded later to use the values of the
 // prompts.
-func (p *Params) ExpandParams(metaKey string) error {
+func (p *Params) ExpandParams(
+	engine *templates.Engine,
+	metaKey string,
+) error {
 	var err error
 
 	// General fields.
 
 	for k, v := range p.Data {
 		ptr := MakeContainerPtr(p.Data, k, []any{})
-		expandParamsVisit(p.Data, ptr, v)
+		expandParamsVisit(engine, p.Data, ptr, v)
 	}
 
 	// Meta fields.
@@ -125,33 +132,139 @@ func (p *Params) ExpandParams(metaKey string) error {
 	for i := range p.Pairs {
 		pair := &p.Pairs[i]
 
-		metaTemplateName := func(field string) string {
-			return utils.PathString(append(pair.Path, field))
+		// Expanded once per item instead.
+		if pair.Foreach != "" {
+			continue
 		}
 
-		pair.Template.Path, err = templates.ExpandString(
-			metaTemplateName("template"),
-			pair.Template.Path,
-			p.Data,
-		)
+		err = pair.Expand(engine, p.Data)
 
 		if err != nil {
 			return err
 		}
+	}
 
-		pair.Output.Path, err = templates.ExpandString(
-			metaTemplateName("output"),
-			pair.Output.Path,
-			p.Data,
+	p.Manifest.Path, err = engine.ExpandString(
+		utils.PathString([]any{metaKey, "manifest"}),
+		p.Manifest.Path,
+		p.Data,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	p.Hooks.Dir.Path, err = engine.ExpandString(
+		utils.PathString([]any{metaKey, "hooks", "dir"}),
+		p.Hooks.Dir.Path,
+		p.Data,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Expands the templated fields of the pair with `data`. Done by
+// `ExpandParams` for every pair but those with `Foreach`, which must be
+// expanded once per item.
+func (pair *ParamsPair) Expand(
+	engine *templates.Engine,
+	data map[string]any,
+) error {
+	var err error
+
+	metaTemplateName := func(field string) string {
+		return utils.PathString(append(pair.Path, field))
+	}
+
+	pair.Template.Path, err = engine.ExpandString(
+		metaTemplateName("template"),
+		pair.Template.Path,
+		data,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	pair.Output.Path, err = engine.ExpandString(
+		metaTemplateName("output"),
+		pair.Output.Path,
+		data,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	pair.Mode, err = engine.ExpandString(
+		metaTemplateName("mode"),
+		pair.Mode,
+		data,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if pair.When != nil {
+		when, err := engine.ExpandString(
+			metaTemplateName("when"),
+			*pair.When,
+			data,
 		)
 
 		if err != nil {
 			return err
 		}
+
+		pair.When = &when
 	}
 
-	if err != nil {
-		return err
+	if pair.Inject != nil {
+		// May be shared with other copies of the pair.
+		inject := *pair.Inject
+		err = inject.expand(engine, pair.Path, data)
+
+		if err != nil {
+			return err
+		}
+
+		pair.Inject = &inject
+	}
+
+	return nil
+}
+
+func (inject *ParamsInject) expand(
+	engine *templates.Engine,
+	pairPath []any,
+	data map[string]any,
+) error {
+	var err error
+
+	fields := []struct {
+		key    string
+		target *string
+	}{
+		{"before", &inject.Before},
+		{"after", &inject.After},
+		{"skip_if", &inject.SkipIf},
+	}
+
+	for _, field := range fields {
+		*field.target, err = engine.ExpandString(
+			utils.PathString(append(pairPath, "inject", field.key)),
+			*field.target,
+			data,
+		)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
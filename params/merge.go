@@ -0,0 +1,113 @@
+package params
+
+import "maps"
+
+// Combines `other` into `p`, with `other` taking precedence.
+//
+// Data is merged recursively: tables present in both are merged, and
+// any other value in `other` replaces the one in `p`. Pairs are
+// concatenated, except for the root pair (`meta.template` and
+// `meta.output`), whose fields are overridden individually. Prompts are
+// overridden by name. Partials and hook commands are concatenated.
+func (p *Params) Merge(other Params) {
+	if p.Data == nil {
+		p.Data = make(map[string]any)
+	}
+
+	mergeMaps(p.Data, other.Data)
+	p.mergePairs(other.Pairs)
+	p.mergePrompts(other)
+
+	if !other.Manifest.IsEmpty() {
+		p.Manifest = other.Manifest
+	}
+
+	p.Partials = append(p.Partials, other.Partials...)
+	p.mergeHooks(other.Hooks)
+
+	if other.TemplateLeftDelim != "" {
+		p.TemplateLeftDelim = other.TemplateLeftDelim
+	}
+
+	if other.TemplateRightDelim != "" {
+		p.TemplateRightDelim = other.TemplateRightDelim
+	}
+
+	if other.TemplateCase != "" {
+		p.TemplateCase = other.TemplateCase
+	}
+}
+
+func mergeMaps(dst, src map[string]any) {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+		} else {
+			dst[key] = srcValue
+		}
+	}
+}
+
+// The root pair is the one defined directly in the `meta` table.
+func (pair ParamsPair) IsRoot() bool {
+	return len(pair.Path) == 1
+}
+
+func (p *Params) mergePairs(others []ParamsPair) {
+	for _, other := range others {
+		if !other.IsRoot() {
+			p.Pairs = append(p.Pairs, other)
+			continue
+		}
+
+		if len(p.Pairs) == 0 || !p.Pairs[0].IsRoot() {
+			// Keep the root pair first.
+			p.Pairs = append([]ParamsPair{other}, p.Pairs...)
+			continue
+		}
+
+		root := &p.Pairs[0]
+
+		if !other.Template.IsEmpty() {
+			root.Template = other.Template
+			root.TemplateSuffix = other.TemplateSuffix
+			root.TemplateSha256 = other.TemplateSha256
+		}
+
+		if !other.Output.IsEmpty() {
+			root.Output = other.Output
+		}
+	}
+}
+
+func (p *Params) mergePrompts(other Params) {
+outer:
+	for _, otherPrompt := range other.Prompt {
+		for i, prompt := range p.Prompt {
+			if prompt.Name == otherPrompt.Name {
+				p.Prompt[i] = otherPrompt
+				continue outer
+			}
+		}
+
+		p.Prompt = append(p.Prompt, otherPrompt)
+	}
+}
+
+func (p *Params) mergeHooks(other ParamsHooks) {
+	p.Hooks.Pre = append(p.Hooks.Pre, other.Pre...)
+	p.Hooks.Post = append(p.Hooks.Post, other.Post...)
+
+	if !other.Dir.IsEmpty() {
+		p.Hooks.Dir = other.Dir
+	}
+
+	if len(other.Env) > 0 && p.Hooks.Env == nil {
+		p.Hooks.Env = make(map[string]string, len(other.Env))
+	}
+
+	maps.Copy(p.Hooks.Env, other.Env)
+}
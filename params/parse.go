@@ -1,16 +1,55 @@
 package params
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"path"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 	"github.com/veigaribo/qveen/prompts"
+	"github.com/veigaribo/qveen/utils"
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	IfExistsOverwrite = "overwrite"
+	IfExistsSkip      = "skip"
+	IfExistsFail      = "fail"
+	IfExistsAsk       = "ask"
+	IfExistsIfChanged = "if_changed"
+)
+
+var IfExistsPolicies = []string{
+	IfExistsOverwrite, IfExistsSkip, IfExistsFail, IfExistsAsk, IfExistsIfChanged,
+}
+
+// Whether the result of expanding a `when` means no. Surrounding
+// whitespace is ignored.
+func IsFalsy(value string) bool {
+	switch strings.TrimSpace(value) {
+	case "", "false", "0", "<no value>":
+		return true
+	}
+
+	return false
+}
+
+const (
+	OutputFormatAuto = "auto"
+	OutputFormatGo   = "go"
+	OutputFormatJson = "json"
+	OutputFormatYaml = "yaml"
+	OutputFormatToml = "toml"
+)
+
+var OutputFormats = []string{
+	OutputFormatAuto, OutputFormatGo, OutputFormatJson, OutputFormatYaml, OutputFormatToml,
+}
+
 type ParamsPathFrom = uint
 
 const (
@@ -21,13 +60,17 @@ const (
 type ParamsPath struct {
 	Path string
 	From ParamsPathFrom
+
+	// The parameter file in which the path was found, so that it may be
+	// resolved relative to it even after merging multiple files.
+	Params string
 }
 
 func (pp ParamsPath) IsEmpty() bool {
 	return pp.Path == ""
 }
 
-func (pp ParamsPath) Resolve(pathToParams string) string {
+func (pp ParamsPath) Resolve() string {
 	if pp.IsEmpty() {
 		return ""
 	}
@@ -37,7 +80,7 @@ func (pp ParamsPath) Resolve(pathToParams string) string {
 	}
 
 	// Must be ParamsPathFromParams.
-	paramsDir := path.Dir(pathToParams)
+	paramsDir := path.Dir(pp.Params)
 	return path.Join(paramsDir, pp.Path)
 }
 
@@ -45,17 +88,91 @@ type ParamsPair struct {
 	Template ParamsPath
 	Output   ParamsPath
 
+	// For directory templates. If set, only files ending with it are
+	// treated as templates, and the others are copied verbatim.
+	TemplateSuffix string
+
+	// Lowercase hexadecimal SHA-256 hash the contents of the template
+	// must have, if set.
+	TemplateSha256 string
+
+	// If set, the result is inserted into the existing output file
+	// instead of replacing it.
+	Inject *ParamsInject
+
+	// What to do when the output file already exists. One of
+	// `IfExistsPolicies`, or empty for the default of asking unless
+	// told otherwise.
+	IfExists string
+
+	// Template expression deciding whether the pair is rendered at all.
+	// Nil means always. See `IsFalsy`.
+	When *string
+
+	// Data path or jq expression yielding the items for which to render
+	// the pair, if it is to be rendered more than once.
+	Foreach string
+
+	// How to reformat the output, if at all. One of `OutputFormats`.
+	Format string
+
+	// Octal permissions for the output, such as `0755`. Empty means
+	// the default, or that of the template file for directory templates.
+	Mode string
+
+	// If set, existing files keep their mode even if `Mode` is set.
+	PreserveMode bool
+
+	// Commands to run after the files of the pair are written.
+	Post []string
+
 	// Because a pair may be found in multiple keys (`meta` or
 	// `meta.pairs[#]`), store it here so we can show appropriate
 	// error messages after parsing.
 	Path []any
 }
 
+// Where to insert content into an existing file. At most one of
+// `Before`, `After` and `At` is set. `Before`, `After` and `SkipIf` are
+// regular expressions.
+type ParamsInject struct {
+	Before string
+	After  string
+
+	// `top` or `bottom`.
+	At string
+
+	// If the file already matches it, nothing is inserted.
+	SkipIf string
+}
+
+// Commands to run around writing the files. They are expanded as
+// templates right before running.
+type ParamsHooks struct {
+	Pre  []string
+	Post []string
+
+	// Working directory of the commands. Empty means the current one.
+	Dir ParamsPath
+
+	// Added to the environment of the commands.
+	Env map[string]string
+}
+
 type Params struct {
 	Data   map[string]any
 	Pairs  []ParamsPair
 	Prompt []prompts.Prompt
 
+	// Where to keep track of generated files, if anywhere.
+	Manifest ParamsPath
+
+	// Files, globs or URLs with template definitions that every
+	// template may use.
+	Partials []ParamsPath
+
+	Hooks ParamsHooks
+
 	TemplateLeftDelim  string
 	TemplateRightDelim string
 	TemplateCase       string
@@ -70,6 +187,9 @@ const (
 
 type ParseParamsOptions struct {
 	MetaKey string
+
+	// Path to the parameter file being parsed.
+	Source string
 }
 
 func ParseParams(
@@ -96,6 +216,20 @@ func ParseParams(
 		return params, err
 	}
 
+	for i := range params.Pairs {
+		pair := &params.Pairs[i]
+		pair.Template.Params = opts.Source
+		pair.Output.Params = opts.Source
+	}
+
+	params.Manifest.Params = opts.Source
+
+	for i := range params.Partials {
+		params.Partials[i].Params = opts.Source
+	}
+
+	params.Hooks.Dir.Params = opts.Source
+
 	return params, nil
 }
 
@@ -115,7 +249,7 @@ func (params *Params) ParseGeneral(
 	case ParamsYamlFormat:
 		err = yaml.Unmarshal(bytes, &params.Data)
 	default:
-		panic(fmt.Errorf("Unrecognized format '%q'", format))
+		return fmt.Errorf("Unrecognized format '%s'", format)
 	}
 
 	if err != nil {
@@ -150,6 +284,46 @@ func (params *Params) ParseMeta(opts ParseParamsOptions) error {
 		return err
 	}
 
+	manifestRaw, ok := meta["manifest"]
+
+	if ok {
+		params.Manifest, err = parsePath(
+			manifestRaw,
+			[]any{opts.MetaKey, "manifest"},
+			mkParsePathErrors{
+				WrongType:          rerr(MakeMetaManifestWrongTypeError),
+				TablePathMissing:   rerr(MakeMetaManifestPathMissingError),
+				TablePathWrongType: rerr(MakeMetaManifestPathWrongTypeError),
+				TableFromWrongType: rerr(MakeMetaManifestFromWrongTypeError),
+				TableFromInvalid:   rerr(MakeMetaManifestFromInvalidError),
+			},
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	partialsRaw, ok := meta["partials"]
+
+	if ok {
+		params.Partials, err = parsePartials(partialsRaw, []any{opts.MetaKey, "partials"})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	hooksRaw, ok := meta["hooks"]
+
+	if ok {
+		params.Hooks, err = parseHooks(hooksRaw, []any{opts.MetaKey, "hooks"})
+
+		if err != nil {
+			return err
+		}
+	}
+
 	leftDelimRaw, ok := meta["left_delim"]
 
 	if ok {
@@ -201,6 +375,7 @@ func (p *Params) parseMetaPairs(
 	meta map[string]any, path []any,
 ) error {
 	var rootTemplate, rootOutput ParamsPath
+	var rootTemplateSuffix, rootTemplateSha256 string
 
 	templateRaw, ok := meta["template"]
 
@@ -214,6 +389,9 @@ func (p *Params) parseMetaPairs(
 				TablePathWrongType: rerr(MakeMetaRootTemplatePathWrongTypeError),
 				TableFromWrongType: rerr(MakeMetaRootTemplateFromWrongTypeError),
 				TableFromInvalid:   rerr(MakeMetaRootTemplateFromInvalidError),
+				TableUrlWrongType:  rerr(MakeMetaRootTemplateUrlWrongTypeError),
+				TableUrlNotUrl:     rerr(MakeMetaRootTemplateUrlNotUrlError),
+				TableUrlConflict:   rerr(MakeMetaRootTemplateUrlConflictError),
 			},
 		)
 
@@ -222,6 +400,27 @@ func (p *Params) parseMetaPairs(
 		}
 
 		rootTemplate = template
+
+		rootTemplateSuffix, err = parseTemplateSuffix(
+			templateRaw,
+			append(path, "template"),
+			rerr(MakeMetaRootTemplateSuffixWrongTypeError),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		rootTemplateSha256, err = parseTemplateSha256(
+			templateRaw,
+			append(path, "template"),
+			rerr(MakeMetaRootTemplateSha256WrongTypeError),
+			rerr(MakeMetaRootTemplateSha256NotHashError),
+		)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	outputRaw, ok := meta["output"]
@@ -248,9 +447,11 @@ func (p *Params) parseMetaPairs(
 
 	if !rootTemplate.IsEmpty() || !rootOutput.IsEmpty() {
 		p.Pairs = append(p.Pairs, ParamsPair{
-			Template: rootTemplate,
-			Output:   rootOutput,
-			Path:     path,
+			Template:       rootTemplate,
+			Output:         rootOutput,
+			TemplateSuffix: rootTemplateSuffix,
+			TemplateSha256: rootTemplateSha256,
+			Path:           path,
 		})
 	}
 
@@ -318,6 +519,9 @@ func parseMetaPair(
 			TablePathWrongType: rerr(MakeMetaPairTemplatePathWrongTypeError),
 			TableFromWrongType: rerr(MakeMetaPairTemplateFromWrongTypeError),
 			TableFromInvalid:   rerr(MakeMetaPairTemplateFromInvalidError),
+			TableUrlWrongType:  rerr(MakeMetaPairTemplateUrlWrongTypeError),
+			TableUrlNotUrl:     rerr(MakeMetaPairTemplateUrlNotUrlError),
+			TableUrlConflict:   rerr(MakeMetaPairTemplateUrlConflictError),
 		},
 	)
 
@@ -325,6 +529,27 @@ func parseMetaPair(
 		return pair, err
 	}
 
+	pair.TemplateSuffix, err = parseTemplateSuffix(
+		templateRaw,
+		append(path, "template"),
+		rerr(MakeMetaPairTemplateSuffixWrongTypeError),
+	)
+
+	if err != nil {
+		return pair, err
+	}
+
+	pair.TemplateSha256, err = parseTemplateSha256(
+		templateRaw,
+		append(path, "template"),
+		rerr(MakeMetaPairTemplateSha256WrongTypeError),
+		rerr(MakeMetaPairTemplateSha256NotHashError),
+	)
+
+	if err != nil {
+		return pair, err
+	}
+
 	outputRaw, ok := entry["output"]
 
 	if !ok {
@@ -343,10 +568,172 @@ func parseMetaPair(
 		},
 	)
 
+	if err != nil {
+		return pair, err
+	}
+
+	ifExistsRaw, ok := entry["if_exists"]
+
+	if ok {
+		pair.IfExists, ok = ifExistsRaw.(string)
+
+		if !ok {
+			return pair, MakeMetaPairIfExistsWrongTypeError(append(path, "if_exists"))
+		}
+
+		if !slices.Contains(IfExistsPolicies, pair.IfExists) {
+			return pair, MakeMetaPairIfExistsInvalidError(append(path, "if_exists"))
+		}
+	}
+
+	whenRaw, ok := entry["when"]
+
+	if ok {
+		switch when := whenRaw.(type) {
+		case string:
+			pair.When = &when
+		case bool:
+			str := strconv.FormatBool(when)
+			pair.When = &str
+		default:
+			return pair, MakeMetaPairWhenWrongTypeError(append(path, "when"))
+		}
+	}
+
+	foreachRaw, ok := entry["foreach"]
+
+	if ok {
+		pair.Foreach, ok = foreachRaw.(string)
+
+		if !ok {
+			return pair, MakeMetaPairForeachWrongTypeError(append(path, "foreach"))
+		}
+	}
+
+	formatRaw, ok := entry["format"]
+
+	if ok {
+		pair.Format, ok = formatRaw.(string)
+
+		if !ok {
+			return pair, MakeMetaPairFormatWrongTypeError(append(path, "format"))
+		}
+
+		if !slices.Contains(OutputFormats, pair.Format) {
+			return pair, MakeMetaPairFormatInvalidError(append(path, "format"))
+		}
+	}
+
+	modeRaw, ok := entry["mode"]
+
+	if ok {
+		switch mode := modeRaw.(type) {
+		case string:
+			pair.Mode = mode
+		case int, int64, uint64:
+			// `755` would be 0o1363 and `0o755` cannot be told apart
+			// from `493`.
+			return pair, MakeMetaPairModeIntegerError(append(path, "mode"))
+		default:
+			return pair, MakeMetaPairModeWrongTypeError(append(path, "mode"))
+		}
+	}
+
+	preserveModeRaw, ok := entry["preserve_mode"]
+
+	if ok {
+		pair.PreserveMode, ok = preserveModeRaw.(bool)
+
+		if !ok {
+			return pair, MakeMetaPairPreserveModeWrongTypeError(append(path, "preserve_mode"))
+		}
+	}
+
+	postRaw, ok := entry["post"]
+
+	if ok {
+		pair.Post, err = parseCommands(
+			postRaw,
+			append(path, "post"),
+			rerr(MakeMetaPairPostWrongTypeError),
+			rerr(MakeMetaPairPostCommandWrongTypeError),
+		)
+
+		if err != nil {
+			return pair, err
+		}
+	}
+
+	injectRaw, ok := entry["inject"]
+
+	if ok {
+		pair.Inject, err = parseInject(injectRaw, append(path, "inject"))
+
+		if err != nil {
+			return pair, err
+		}
+	}
+
 	pair.Path = path
 	return pair, nil
 }
 
+func parseInject(obj any, path []any) (*ParamsInject, error) {
+	m, ok := obj.(map[string]any)
+
+	if !ok {
+		return nil, MakeMetaPairInjectWrongTypeError(path)
+	}
+
+	var inject ParamsInject
+
+	fields := []struct {
+		key            string
+		target         *string
+		mkWrongTypeErr func(path []any) error
+	}{
+		{"before", &inject.Before, rerr(MakeMetaPairInjectBeforeWrongTypeError)},
+		{"after", &inject.After, rerr(MakeMetaPairInjectAfterWrongTypeError)},
+		{"at", &inject.At, rerr(MakeMetaPairInjectAtWrongTypeError)},
+		{"skip_if", &inject.SkipIf, rerr(MakeMetaPairInjectSkipIfWrongTypeError)},
+	}
+
+	anchors := 0
+
+	for _, field := range fields {
+		raw, ok := m[field.key]
+
+		if !ok {
+			continue
+		}
+
+		*field.target, ok = raw.(string)
+
+		if !ok {
+			return nil, field.mkWrongTypeErr(append(path, field.key))
+		}
+
+		if field.key != "skip_if" {
+			anchors++
+
+			if anchors > 1 {
+				return nil, MakeMetaPairInjectAnchorConflictError(append(path, field.key))
+			}
+		}
+	}
+
+	if inject.At != "" && !slices.Contains([]string{"top", "bottom"}, inject.At) {
+		return nil, MakeMetaPairInjectAtInvalidError(append(path, "at"))
+	}
+
+	// Default.
+	if anchors == 0 {
+		inject.At = "bottom"
+	}
+
+	return &inject, nil
+}
+
 func (p *Params) parseMetaPrompts(
 	meta map[string]any, path []any,
 ) error {
@@ -509,6 +896,12 @@ type mkParsePathErrors struct {
 	TablePathWrongType func(path []any) error
 	TableFromWrongType func(path []any) error
 	TableFromInvalid   func(path []any) error
+
+	// Only for paths that may be given as `url` instead of `path` in
+	// the table form. Nil if they may not.
+	TableUrlWrongType func(path []any) error
+	TableUrlNotUrl    func(path []any) error
+	TableUrlConflict  func(path []any) error
 }
 
 func parsePath(
@@ -535,6 +928,26 @@ func parsePath(
 		return result, mkerr.WrongType(path)
 	}
 
+	urlRaw, ok := m["url"]
+
+	if ok && mkerr.TableUrlWrongType != nil {
+		if _, ok := m["path"]; ok {
+			return result, mkerr.TableUrlConflict(path)
+		}
+
+		result.Path, ok = urlRaw.(string)
+
+		if !ok {
+			return result, mkerr.TableUrlWrongType(append(path, "url"))
+		}
+
+		if !utils.IsUrl(result.Path) {
+			return result, mkerr.TableUrlNotUrl(append(path, "url"))
+		}
+
+		return result, nil
+	}
+
 	pathRaw, ok := m["path"]
 
 	if !ok {
@@ -572,3 +985,211 @@ func parsePath(
 postFrom:
 	return result, nil
 }
+
+// `suffix` may only be present in the table form of `template`.
+func parseTemplateSuffix(
+	obj any,
+	path []any,
+	mkWrongTypeErr func(path []any) error,
+) (string, error) {
+	m, ok := obj.(map[string]any)
+
+	if !ok {
+		return "", nil
+	}
+
+	suffixRaw, ok := m["suffix"]
+
+	if !ok {
+		return "", nil
+	}
+
+	suffix, ok := suffixRaw.(string)
+
+	if !ok {
+		return "", mkWrongTypeErr(append(path, "suffix"))
+	}
+
+	return suffix, nil
+}
+
+// An array of paths.
+func parsePartials(obj any, path []any) ([]ParamsPath, error) {
+	arr, ok := obj.([]any)
+
+	if !ok {
+		return nil, MakeMetaPartialsWrongTypeError(path)
+	}
+
+	partials := make([]ParamsPath, 0, len(arr))
+
+	for i, partialRaw := range arr {
+		partial, err := parsePath(
+			partialRaw,
+			append(path, i),
+			mkParsePathErrors{
+				WrongType:          rerr(MakeMetaPartialWrongTypeError),
+				TablePathMissing:   rerr(MakeMetaPartialPathMissingError),
+				TablePathWrongType: rerr(MakeMetaPartialPathWrongTypeError),
+				TableFromWrongType: rerr(MakeMetaPartialFromWrongTypeError),
+				TableFromInvalid:   rerr(MakeMetaPartialFromInvalidError),
+			},
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		partials = append(partials, partial)
+	}
+
+	return partials, nil
+}
+
+// `sha256` may only be present in the table form of `template`.
+func parseTemplateSha256(
+	obj any,
+	path []any,
+	mkWrongTypeErr func(path []any) error,
+	mkNotHashErr func(path []any) error,
+) (string, error) {
+	m, ok := obj.(map[string]any)
+
+	if !ok {
+		return "", nil
+	}
+
+	sumRaw, ok := m["sha256"]
+
+	if !ok {
+		return "", nil
+	}
+
+	sum, ok := sumRaw.(string)
+
+	if !ok {
+		return "", mkWrongTypeErr(append(path, "sha256"))
+	}
+
+	sum = strings.ToLower(sum)
+	_, err := hex.DecodeString(sum)
+
+	if err != nil || len(sum) != 64 {
+		return "", mkNotHashErr(append(path, "sha256"))
+	}
+
+	return sum, nil
+}
+
+func parseHooks(obj any, path []any) (ParamsHooks, error) {
+	var hooks ParamsHooks
+	var err error
+
+	m, ok := obj.(map[string]any)
+
+	if !ok {
+		return hooks, MakeMetaHooksWrongTypeError(path)
+	}
+
+	preRaw, ok := m["pre"]
+
+	if ok {
+		hooks.Pre, err = parseCommands(
+			preRaw,
+			append(path, "pre"),
+			rerr(MakeMetaHooksPreWrongTypeError),
+			rerr(MakeMetaHooksPreCommandWrongTypeError),
+		)
+
+		if err != nil {
+			return hooks, err
+		}
+	}
+
+	postRaw, ok := m["post"]
+
+	if ok {
+		hooks.Post, err = parseCommands(
+			postRaw,
+			append(path, "post"),
+			rerr(MakeMetaHooksPostWrongTypeError),
+			rerr(MakeMetaHooksPostCommandWrongTypeError),
+		)
+
+		if err != nil {
+			return hooks, err
+		}
+	}
+
+	dirRaw, ok := m["dir"]
+
+	if ok {
+		hooks.Dir, err = parsePath(
+			dirRaw,
+			append(path, "dir"),
+			mkParsePathErrors{
+				WrongType:          rerr(MakeMetaHooksDirWrongTypeError),
+				TablePathMissing:   rerr(MakeMetaHooksDirPathMissingError),
+				TablePathWrongType: rerr(MakeMetaHooksDirPathWrongTypeError),
+				TableFromWrongType: rerr(MakeMetaHooksDirFromWrongTypeError),
+				TableFromInvalid:   rerr(MakeMetaHooksDirFromInvalidError),
+			},
+		)
+
+		if err != nil {
+			return hooks, err
+		}
+	}
+
+	envRaw, ok := m["env"]
+
+	if ok {
+		env, ok := envRaw.(map[string]any)
+
+		if !ok {
+			return hooks, MakeMetaHooksEnvWrongTypeError(append(path, "env"))
+		}
+
+		hooks.Env = make(map[string]string, len(env))
+
+		for key, valueRaw := range env {
+			value, ok := valueRaw.(string)
+
+			if !ok {
+				return hooks, MakeMetaHooksEnvValueWrongTypeError(append(path, "env", key))
+			}
+
+			hooks.Env[key] = value
+		}
+	}
+
+	return hooks, nil
+}
+
+// An array of strings.
+func parseCommands(
+	obj any,
+	path []any,
+	mkWrongTypeErr func(path []any) error,
+	mkCommandWrongTypeErr func(path []any) error,
+) ([]string, error) {
+	arr, ok := obj.([]any)
+
+	if !ok {
+		return nil, mkWrongTypeErr(path)
+	}
+
+	commands := make([]string, 0, len(arr))
+
+	for i, commandRaw := range arr {
+		command, ok := commandRaw.(string)
+
+		if !ok {
+			return nil, mkCommandWrongTypeErr(append(path, i))
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
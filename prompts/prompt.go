@@ -90,6 +90,31 @@ func AskConfirm(title string) bool {
 	return confirm
 }
 
+type OverwriteChoice uint
+
+const (
+	OverwriteNo OverwriteChoice = iota
+	OverwriteYes
+	OverwriteShowDiff
+)
+
+// Like `AskConfirm`, but also offers to show what would change.
+func AskOverwrite(title string) OverwriteChoice {
+	var choice OverwriteChoice
+
+	huh.NewSelect[OverwriteChoice]().
+		Title(title).
+		Options(
+			huh.NewOption("Yes", OverwriteYes),
+			huh.NewOption("No", OverwriteNo),
+			huh.NewOption("Show diff", OverwriteShowDiff),
+		).
+		Value(&choice).
+		Run()
+
+	return choice
+}
+
 func DoPrompt(prompts []Prompt) (map[string]any, error) {
 	var err error
 
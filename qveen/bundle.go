@@ -0,0 +1,653 @@
+package qveen
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Name of the file, at the root of a bundle, describing its contents.
+const BundleManifestName = "qveen-bundle.json"
+
+const bundleVersion = 1
+
+type BundleFormat string
+
+const (
+	BundleTarGz BundleFormat = "tar.gz"
+	BundleZip   BundleFormat = "zip"
+)
+
+// The format of a bundle at `path`, going by its extension, or empty
+// if it does not look like one.
+func BundleFormatFor(path string) BundleFormat {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return BundleTarGz
+	case strings.HasSuffix(path, ".zip"):
+		return BundleZip
+	}
+
+	return ""
+}
+
+func IsBundle(path string) bool {
+	return BundleFormatFor(path) != ""
+}
+
+// Describes a bundle, which is an archive with parameter files and
+// everything they need to be rendered.
+type BundleManifest struct {
+	Version int `json:"version"`
+
+	// Directory, inside the archive, standing for the one that `pack`
+	// was run from. Paths read from the bundle are relative to it.
+	Dir string `json:"dir"`
+
+	// Parameter files to render, relative to `Dir`.
+	Params []string `json:"params"`
+
+	// Every file in the archive but the manifest.
+	Files []string `json:"files"`
+}
+
+// Writes the parameter files, along with every local partial and
+// template they refer to, into `w` as a bundle. URLs are left as they
+// are.
+func (renderer *Renderer) Pack(
+	ctx context.Context,
+	w io.Writer,
+	format BundleFormat,
+	paramsPaths ...string,
+) (BundleManifest, error) {
+	var manifest BundleManifest
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return manifest, MakeRenderError(IOErrorKind, err)
+	}
+
+	relParams := make([]string, 0, len(paramsPaths))
+
+	for _, paramsPath := range paramsPaths {
+		if !utils.IsLocal(paramsPath) || IsBundle(paramsPath) {
+			return manifest, MakeRenderError(
+				MetaValidationErrorKind,
+				fmt.Errorf("Cannot pack '%s', only local parameter files can be", paramsPath),
+			)
+		}
+
+		// So that paths relative to it do not come out absolute.
+		rel, err := relativeTo(cwd, paramsPath)
+
+		if err != nil {
+			return manifest, MakeRenderError(IOErrorKind, err)
+		}
+
+		relParams = append(relParams, rel)
+	}
+
+	recorder := &recordingFS{
+		fsys:  renderer.opts.Source,
+		files: make(map[string]bool),
+	}
+
+	recording := *renderer
+	recording.opts.Source = recorder
+	recording.opts.Session = nil
+
+	err = recording.collectSources(ctx, relParams)
+
+	if err != nil {
+		return manifest, err
+	}
+
+	sources := recorder.Files()
+	manifest, err = makeBundleManifest(cwd, relParams, sources)
+
+	if err != nil {
+		return manifest, MakeRenderError(MetaValidationErrorKind, err)
+	}
+
+	err = writeBundle(w, format, manifest, recorder.fsys, sources)
+
+	if err != nil {
+		return manifest, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to write bundle: %w", err),
+		)
+	}
+
+	return manifest, nil
+}
+
+// Reads the parameter files, their partials and the template of every
+// pair, whether it would be rendered this time or not. Nothing is
+// prompted, and outputs are not looked at, so template paths are
+// expanded with the data in the parameter files and the prompt values
+// given upfront only.
+func (renderer *Renderer) collectSources(ctx context.Context, paramsPaths []string) error {
+	var p params.Params
+	opts := renderer.opts
+
+	for _, paramsPath := range paramsPaths {
+		fileParams, err := renderer.parseParamsFile(ctx, paramsPath)
+
+		if err != nil {
+			return err
+		}
+
+		p.Merge(fileParams)
+	}
+
+	engine, err := renderer.newEngine(ctx, p)
+
+	if err != nil {
+		return err
+	}
+
+	err = renderer.prefillPrompts(p)
+
+	if err != nil {
+		return err
+	}
+
+	for _, prompt := range p.Prompt {
+		if prompt.Value != nil {
+			p.Data[prompt.Name] = prompt.Value
+		}
+	}
+
+	err = p.ExpandParams(engine, opts.MetaKey)
+
+	if err != nil {
+		return MakeRenderError(
+			templateErrorKind(err),
+			fmt.Errorf("Failed to expand parameters: %w", err),
+		)
+	}
+
+	instances, err := instantiatePairs(engine, p)
+
+	if err != nil {
+		return err
+	}
+
+	type source struct {
+		Index int
+		Path  string
+	}
+
+	var templatePaths []source
+
+	for _, instance := range instances {
+		templatePaths = append(templatePaths, source{instance.Index, instance.Pair.Template.Resolve()})
+
+		if opts.TemplatePath != "" && len(p.Pairs) == 1 {
+			templatePath, err := engine.ExpandString("--template", opts.TemplatePath, instance.Data)
+
+			if err != nil {
+				return MakeRenderError(
+					templateErrorKind(err),
+					fmt.Errorf("Failed to expand template path: %w", err),
+				)
+			}
+
+			templatePaths = append(templatePaths, source{instance.Index, templatePath})
+		}
+	}
+
+	// Pairs without items this time may have some when rendering from
+	// the bundle.
+	for i, pair := range p.Pairs {
+		if pair.Foreach != "" && !engine.IsTemplated(pair.Template.Path) {
+			templatePaths = append(templatePaths, source{i, pair.Template.Resolve()})
+		}
+	}
+
+	for _, templatePath := range templatePaths {
+		if templatePath.Path == "" || !utils.IsLocal(templatePath.Path) {
+			continue
+		}
+
+		err := readAll(renderer.opts.Source, templatePath.Path)
+
+		if err != nil {
+			return MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to read template of pair #%d: %w", templatePath.Index, err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Puts every path in `files` relative to the closest directory
+// containing both them and `cwd`, in the same order.
+func makeBundleManifest(
+	cwd string,
+	paramsPaths []string,
+	files []string,
+) (BundleManifest, error) {
+	manifest := BundleManifest{
+		Version: bundleVersion,
+		Params:  make([]string, 0, len(paramsPaths)),
+		Files:   make([]string, 0, len(files)),
+	}
+
+	for _, file := range files {
+		if filepath.IsAbs(file) {
+			return manifest, fmt.Errorf("Cannot pack '%s', absolute paths would not be found in the bundle", file)
+		}
+	}
+
+	root := cwd
+
+	for _, file := range files {
+		abs := filepath.Join(cwd, file)
+
+		for !isWithin(root, abs) {
+			root = filepath.Dir(root)
+		}
+	}
+
+	dir, err := relativeTo(root, cwd)
+
+	if err != nil {
+		return manifest, err
+	}
+
+	manifest.Dir = dir
+
+	for _, paramsPath := range paramsPaths {
+		manifest.Params = append(manifest.Params, filepath.ToSlash(paramsPath))
+	}
+
+	for _, file := range files {
+		rel, err := relativeTo(root, filepath.Join(cwd, file))
+
+		if err != nil {
+			return manifest, err
+		}
+
+		manifest.Files = append(manifest.Files, rel)
+	}
+
+	return manifest, nil
+}
+
+// `sources` are where each of `manifest.Files` is read from in `fsys`.
+func writeBundle(
+	w io.Writer,
+	format BundleFormat,
+	manifest BundleManifest,
+	fsys fs.FS,
+	sources []string,
+) error {
+	manifestContent, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	manifestContent = append(manifestContent, '\n')
+
+	type bundleFile struct {
+		Name    string
+		Content []byte
+		Mode    fs.FileMode
+	}
+
+	files := []bundleFile{{
+		Name:    BundleManifestName,
+		Content: manifestContent,
+		Mode:    0644,
+	}}
+
+	for i, name := range manifest.Files {
+		source := sources[i]
+		content, err := fs.ReadFile(fsys, source)
+
+		if err != nil {
+			return err
+		}
+
+		stat, err := fs.Stat(fsys, source)
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, bundleFile{
+			Name:    name,
+			Content: content,
+			Mode:    stat.Mode().Perm(),
+		})
+	}
+
+	switch format {
+	case BundleTarGz:
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+
+		for _, file := range files {
+			err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     file.Name,
+				Size:     int64(len(file.Content)),
+				Mode:     int64(file.Mode),
+			})
+
+			if err != nil {
+				return err
+			}
+
+			_, err = tw.Write(file.Content)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tw.Close()
+
+		if err != nil {
+			return err
+		}
+
+		return gz.Close()
+	case BundleZip:
+		zw := zip.NewWriter(w)
+
+		for _, file := range files {
+			header := &zip.FileHeader{
+				Name:   file.Name,
+				Method: zip.Deflate,
+			}
+
+			header.SetMode(file.Mode)
+			fw, err := zw.CreateHeader(header)
+
+			if err != nil {
+				return err
+			}
+
+			_, err = fw.Write(file.Content)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return zw.Close()
+	}
+
+	return fmt.Errorf("Unknown bundle format '%s'", format)
+}
+
+// Loads the bundle at `bundlePath` and gives a renderer reading from it
+// instead of from `Options.Source`, along with the parameter files to
+// render with it.
+func (renderer *Renderer) openBundle(
+	ctx context.Context,
+	bundlePath string,
+) (*Renderer, []string, error) {
+	reader, err := renderer.open(ctx, bundlePath)
+
+	if err != nil {
+		return nil, nil, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to open bundle '%s': %w", bundlePath, err),
+		)
+	}
+
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+
+	if err != nil {
+		return nil, nil, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to read bundle '%s': %w", bundlePath, err),
+		)
+	}
+
+	files, manifest, err := readBundle(BundleFormatFor(bundlePath), content)
+
+	if err != nil {
+		return nil, nil, MakeRenderError(
+			ParamsParseErrorKind,
+			fmt.Errorf("Invalid bundle '%s': %w", bundlePath, err),
+		)
+	}
+
+	bundled := *renderer
+	bundled.opts.Source = bundleFS{files, manifest.Dir}
+	return &bundled, manifest.Params, nil
+}
+
+func readBundle(format BundleFormat, content []byte) (MemFS, BundleManifest, error) {
+	var manifest BundleManifest
+	files := make(MemFS)
+
+	switch format {
+	case BundleTarGz:
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+
+		if err != nil {
+			return nil, manifest, err
+		}
+
+		tr := tar.NewReader(gz)
+
+		for {
+			header, err := tr.Next()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, manifest, err
+			}
+
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			data, err := io.ReadAll(tr)
+
+			if err != nil {
+				return nil, manifest, err
+			}
+
+			files[path.Clean(header.Name)] = &fstest.MapFile{
+				Data: data,
+				Mode: fs.FileMode(header.Mode).Perm(),
+			}
+		}
+	case BundleZip:
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+		if err != nil {
+			return nil, manifest, err
+		}
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+
+			fr, err := f.Open()
+
+			if err != nil {
+				return nil, manifest, err
+			}
+
+			data, err := io.ReadAll(fr)
+			fr.Close()
+
+			if err != nil {
+				return nil, manifest, err
+			}
+
+			files[path.Clean(f.Name)] = &fstest.MapFile{
+				Data: data,
+				Mode: f.Mode().Perm(),
+			}
+		}
+	default:
+		return nil, manifest, fmt.Errorf("Unknown bundle format '%s'", format)
+	}
+
+	for name := range files {
+		if !fs.ValidPath(name) {
+			return nil, manifest, fmt.Errorf("'%s' is outside of the bundle", name)
+		}
+	}
+
+	manifestFile, ok := files[BundleManifestName]
+
+	if !ok {
+		return nil, manifest, fmt.Errorf("Missing %s", BundleManifestName)
+	}
+
+	err := json.Unmarshal(manifestFile.Data, &manifest)
+
+	if err != nil {
+		return nil, manifest, fmt.Errorf("Invalid %s: %w", BundleManifestName, err)
+	}
+
+	if manifest.Version != bundleVersion {
+		return nil, manifest, fmt.Errorf("Unsupported version %d", manifest.Version)
+	}
+
+	if len(manifest.Params) == 0 {
+		return nil, manifest, errors.New("No parameter files")
+	}
+
+	if manifest.Dir != "" && !fs.ValidPath(manifest.Dir) {
+		return nil, manifest, fmt.Errorf("Directory '%s' is outside of the bundle", manifest.Dir)
+	}
+
+	return files, manifest, nil
+}
+
+// The contents of a bundle, seen from the directory `pack` was run
+// from, so that paths going up from it still work.
+type bundleFS struct {
+	files MemFS
+	dir   string
+}
+
+func (b bundleFS) Open(name string) (fs.File, error) {
+	full := path.Join(b.dir, name)
+
+	if !fs.ValidPath(full) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return b.files.Open(full)
+}
+
+// Keeps track of which regular files were opened.
+type recordingFS struct {
+	fsys fs.FS
+
+	mutex sync.Mutex
+	files map[string]bool
+}
+
+func (r *recordingFS) Open(name string) (fs.File, error) {
+	file, err := r.fsys.Open(name)
+
+	if err != nil {
+		return file, err
+	}
+
+	stat, err := file.Stat()
+
+	if err == nil && stat.Mode().IsRegular() {
+		r.mutex.Lock()
+		r.files[name] = true
+		r.mutex.Unlock()
+	}
+
+	return file, nil
+}
+
+// Sorted.
+func (r *recordingFS) Files() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	files := make([]string, 0, len(r.files))
+
+	for file := range r.files {
+		files = append(files, file)
+	}
+
+	slices.Sort(files)
+	return files
+}
+
+// Opens every file at `name`, or every file under it if a directory.
+func readAll(fsys fs.FS, name string) error {
+	return fs.WalkDir(fsys, fsPath(name), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		_, err = fs.ReadFile(fsys, p)
+		return err
+	})
+}
+
+// Whether `path` is `dir` or inside of it. Both must be clean and
+// absolute.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Slash-separated.
+func relativeTo(base, target string) (string, error) {
+	if !filepath.IsAbs(target) {
+		cwd, err := os.Getwd()
+
+		if err != nil {
+			return "", err
+		}
+
+		target = filepath.Join(cwd, target)
+	}
+
+	rel, err := filepath.Rel(base, target)
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
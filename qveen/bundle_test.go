@@ -0,0 +1,174 @@
+package qveen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// A .tar.gz with `files` in it as they are, valid bundle or not.
+func testTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	gz := gzip.NewWriter(&buffer)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Size:     int64(len(content)),
+			Mode:     0644,
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = tw.Write([]byte(content))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	for _, format := range []BundleFormat{BundleTarGz, BundleZip} {
+		t.Run(string(format), func(t *testing.T) {
+			source := fstest.MapFS{
+				"gen/params.toml": mapFile(`
+tables = ["users", "orders"]
+
+[meta]
+partials = [{ path = "lib.tmpl", from = "params" }]
+
+[[meta.pairs]]
+template = { path = "model.tmpl", from = "params" }
+output = "{{.item}}.txt"
+foreach = "tables"
+`),
+				"gen/model.tmpl":  mapFile("{{t \"title\" .item}}\n"),
+				"gen/lib.tmpl":    mapFile("{{def \"title\"}}Model {{.}}{{end}}\n"),
+				"gen/unused.tmpl": mapFile("Not packed\n"),
+			}
+
+			var bundle bytes.Buffer
+			manifest, err := testRenderer(t, source, MemFS{}).
+				Pack(context.Background(), &bundle, format, "gen/params.toml")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []string{"gen/lib.tmpl", "gen/model.tmpl", "gen/params.toml"}
+
+			if !slices.Equal(manifest.Files, expected) {
+				t.Errorf("Expected %v to be packed, got %v", expected, manifest.Files)
+			}
+
+			bundleName := "gen." + string(format)
+			bundleSource := fstest.MapFS{
+				bundleName: &fstest.MapFile{Data: bundle.Bytes(), Mode: 0644},
+			}
+
+			output := MemFS{}
+			_, err = testRenderer(t, bundleSource, output).Render(context.Background(), bundleName)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertFile(t, output, "users.txt", "Model users\n")
+			assertFile(t, output, "orders.txt", "Model orders\n")
+		})
+	}
+}
+
+func TestBundleRejectsEscapingPaths(t *testing.T) {
+	tests := map[string]struct {
+		kind  ErrorKind
+		files map[string]string
+	}{
+		"entry": {ParamsParseErrorKind, map[string]string{
+			BundleManifestName: `{"version": 1, "dir": "", "params": ["params.toml"], "files": ["params.toml", "../outside.tmpl"]}`,
+			"params.toml":      "[meta]\ntemplate = \"template.tmpl\"\noutput = \"out.txt\"\n",
+			"../outside.tmpl":  "Outside\n",
+		}},
+		"dir": {ParamsParseErrorKind, map[string]string{
+			BundleManifestName: `{"version": 1, "dir": "..", "params": ["params.toml"], "files": ["params.toml"]}`,
+			"params.toml":      "[meta]\ntemplate = \"outside.tmpl\"\noutput = \"out.txt\"\n",
+		}},
+		"template": {IOErrorKind, map[string]string{
+			BundleManifestName: `{"version": 1, "dir": "", "params": ["params.toml"], "files": ["params.toml"]}`,
+			"params.toml":      "[meta]\ntemplate = \"../outside.tmpl\"\noutput = \"out.txt\"\n",
+		}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := fstest.MapFS{
+				"bundle.tar.gz":   &fstest.MapFile{Data: testTarGz(t, test.files), Mode: 0644},
+				"outside.tmpl":    mapFile("Outside\n"),
+				"../outside.tmpl": mapFile("Outside\n"),
+			}
+
+			output := MemFS{}
+			_, err := testRenderer(t, source, output).Render(context.Background(), "bundle.tar.gz")
+
+			var renderErr RenderError
+
+			if !errors.As(err, &renderErr) || renderErr.Kind != test.kind {
+				t.Errorf("Expected an error of kind %d, got %v", test.kind, err)
+			}
+
+			if len(output) != 0 {
+				t.Errorf("Expected nothing to be written, got %d files", len(output))
+			}
+		})
+	}
+}
+
+func TestBundleWithoutParams(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no manifest": {
+			"params.toml": "[meta]\ntemplate = \"template.tmpl\"\noutput = \"out.txt\"\n",
+		},
+		"no params": {
+			BundleManifestName: `{"version": 1, "dir": "", "params": [], "files": ["template.tmpl"]}`,
+			"template.tmpl":    "Template\n",
+		},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			source := fstest.MapFS{
+				"bundle.tar.gz": &fstest.MapFile{Data: testTarGz(t, files), Mode: 0644},
+			}
+
+			_, err := testRenderer(t, source, MemFS{}).Render(context.Background(), "bundle.tar.gz")
+
+			var renderErr RenderError
+
+			if !errors.As(err, &renderErr) || renderErr.Kind != ParamsParseErrorKind {
+				t.Errorf("Expected a params parse error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package qveen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Keeps files fetched from URLs on disk. Contents are stored by their
+// SHA-256 hash, so that pinned files may be found without knowing where
+// they came from, and each URL points to the contents it last had.
+type Cache struct {
+	Dir string
+}
+
+// `$QVEEN_CACHE_DIR`, or a `qveen` directory in the user cache
+// directory of the system.
+func DefaultCacheDir() (string, error) {
+	dir := os.Getenv("QVEEN_CACHE_DIR")
+
+	if dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "qveen"), nil
+}
+
+// What is known of a URL from when it was last fetched.
+type cacheEntry struct {
+	Url          string `json:"url"`
+	Sha256       string `json:"sha256"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Opens the cached file whose contents hash to `sum`.
+func (c *Cache) Open(sum string) (io.ReadCloser, error) {
+	return os.Open(c.contentPath(sum))
+}
+
+func (c *Cache) contentPath(sum string) string {
+	return filepath.Join(c.Dir, "sha256", sum)
+}
+
+func (c *Cache) entryPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, "urls", hex.EncodeToString(sum[:])+".json")
+}
+
+// The entry for `url`, if there is one and its contents are still
+// there.
+func (c *Cache) lookup(url string) (cacheEntry, bool) {
+	var entry cacheEntry
+	content, err := os.ReadFile(c.entryPath(url))
+
+	if err != nil {
+		return entry, false
+	}
+
+	err = json.Unmarshal(content, &entry)
+
+	if err != nil || entry.Url != url {
+		return entry, false
+	}
+
+	_, err = os.Stat(c.contentPath(entry.Sha256))
+	return entry, err == nil
+}
+
+// Keeps `content` and points `entry.Url` to it. `entry.Sha256` is
+// filled in.
+func (c *Cache) store(entry cacheEntry, content []byte) error {
+	sum := sha256.Sum256(content)
+	entry.Sha256 = hex.EncodeToString(sum[:])
+
+	contentPath := c.contentPath(entry.Sha256)
+	_, err := os.Stat(contentPath)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		err = writeAtomically(contentPath, content)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	return writeAtomically(c.entryPath(entry.Url), encoded)
+}
+
+// So that other processes never see half of a file.
+func writeAtomically(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0777)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, ".tmp-*")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	closeErr := file.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// Fails unless `content` hashes to `sum`.
+func checkSha256(content []byte, sum string) error {
+	actual := sha256.Sum256(content)
+	actualHex := hex.EncodeToString(actual[:])
+
+	if actualHex != sum {
+		return fmt.Errorf("Expected SHA-256 %s, got %s", sum, actualHex)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Deletes the files in the manifest that were generated from the same
+// parameter files but that would not be generated anymore.
+func (renderer *Renderer) Clean(ctx context.Context, paramsPaths ...string) error {
+	opts := renderer.opts
+	prep, err := renderer.prepare(ctx, paramsPaths, &Report{})
+
+	if err != nil {
+		return err
+	}
+
+	if prep.ManifestPath == "" {
+		return MakeRenderError(
+			MetaValidationErrorKind,
+			errors.New("No manifest to clean up with. Set `meta.manifest` or `--manifest`."),
+		)
+	}
+
+	manifest, err := LoadManifest(opts.Output, prep.ManifestPath)
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to load manifest: %w", err),
+		)
+	}
+
+	produced := make([]string, 0, len(prep.Plan.Entries))
+
+	for _, entry := range prep.Plan.Entries {
+		if entry.Rendered.SkipReason == "" {
+			produced = append(produced, entry.Rendered.OutputPath)
+		}
+	}
+
+	stale, err := manifest.Stale(paramsPaths, produced)
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to compare with manifest: %w", err),
+		)
+	}
+
+	if len(stale) == 0 {
+		fmt.Fprintln(opts.Stderr, "Nothing to clean.")
+		return nil
+	}
+
+	for _, key := range stale {
+		path := manifest.Resolve(key)
+		_, modified, err := manifest.Check(path)
+
+		if err != nil {
+			return MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to check '%s': %w", path, err),
+			)
+		}
+
+		if modified {
+			fmt.Fprintln(opts.Stderr, path, "(edited since it was generated)")
+		} else {
+			fmt.Fprintln(opts.Stderr, path)
+		}
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+
+	if !opts.Overwrite {
+		title := fmt.Sprintf("Delete the %d files above?", len(stale))
+		ok, err := opts.Prompter.Confirm(title)
+
+		if err != nil {
+			return MakeRenderError(PromptErrorKind, err)
+		}
+
+		if !ok {
+			return MakeRenderError(AbortedErrorKind, utils.ErrAborted)
+		}
+	}
+
+	paths := make([]string, 0, len(stale))
+
+	for _, key := range stale {
+		paths = append(paths, manifest.Resolve(key))
+		manifest.Forget(key)
+	}
+
+	err = opts.Output.RemoveFiles(ctx, paths, filepath.Dir(manifest.Path()))
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to delete files: %w", err),
+		)
+	}
+
+	content, err := manifest.Content()
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to update manifest: %w", err),
+		)
+	}
+
+	err = opts.Output.WriteFiles(ctx, []OutputFile{{
+		Path:    manifest.Path(),
+		Content: content,
+	}})
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to update manifest: %w", err),
+		)
+	}
+
+	return nil
+}
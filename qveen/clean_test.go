@@ -0,0 +1,62 @@
+package qveen
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCleanWithoutPairs(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+dir = "generated"
+
+[meta]
+manifest = "{{.dir}}/manifest.json"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "{{.dir}}/a.txt"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "{{.dir}}/b.txt"
+`),
+		"template.tmpl": mapFile("generated\n"),
+	}
+
+	output := MemFS{
+		"generated/manual.txt": mapFile("manual\n"),
+	}
+
+	_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every pair removed, so everything generated from it is stale.
+	source["params.toml"] = mapFile(`
+dir = "generated"
+
+[meta]
+manifest = "{{.dir}}/manifest.json"
+`)
+
+	renderer := testRenderer(t, source, output)
+	renderer.opts.Overwrite = true
+	err = renderer.Clean(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"generated/a.txt", "generated/b.txt"} {
+		if _, ok := output[name]; ok {
+			t.Errorf("Expected '%s' to be deleted", name)
+		}
+	}
+
+	assertFile(t, output, "generated/manual.txt", "manual\n")
+	assertFile(t, output, "generated/manifest.json", "{\n  \"files\": {}\n}\n")
+}
@@ -0,0 +1,39 @@
+package qveen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/veigaribo/qveen/utils"
+)
+
+const diffContext = 3
+
+// Prints what would change in the output file in `fsys` if `r` were
+// written. Uses colors if `w` is a terminal.
+func PrintDiff(w io.Writer, fsys fs.FS, r RenderedPair) error {
+	existing, err := fs.ReadFile(fsys, fsPath(r.OutputPath))
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	diff := utils.UnifiedDiff(
+		"a/"+r.OutputPath,
+		"b/"+r.OutputPath,
+		string(existing),
+		string(r.Content),
+		diffContext,
+	)
+
+	if f, ok := w.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		diff = utils.ColorDiff(diff)
+	}
+
+	_, err = fmt.Fprint(w, diff)
+	return err
+}
@@ -0,0 +1,289 @@
+package qveen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/veigaribo/qveen/templates"
+)
+
+// Name of the file, at the root of a template directory, listing paths
+// to leave out of the output.
+const IgnoreFileName = ".qveenignore"
+
+// A file found inside a template directory.
+type DirectoryEntry struct {
+	// Path to the file, including the template directory.
+	Path string
+
+	// Slash-separated path relative to the template directory, with the
+	// suffix removed if any.
+	Name string
+
+	// Whether the file should be copied as is instead of executed.
+	Verbatim bool
+}
+
+// Lists the files in the template directory `root` of `fsys`, minus
+// ignored ones.
+//
+// If `suffix` is not empty, only files ending with it are considered
+// templates. Otherwise, every file is.
+func ListDirectory(fsys fs.FS, root, suffix string) ([]DirectoryEntry, error) {
+	root = fsPath(root)
+	ignore, err := readIgnoreFile(fsys, path.Join(root, IgnoreFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DirectoryEntry
+
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if rel == IgnoreFileName || ignore.Matches(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		entry := DirectoryEntry{
+			Path: p,
+			Name: rel,
+		}
+
+		if suffix != "" {
+			if strings.HasSuffix(rel, suffix) {
+				entry.Name = strings.TrimSuffix(rel, suffix)
+			} else {
+				entry.Verbatim = true
+			}
+		}
+
+		entries = append(entries, entry)
+		return nil
+	})
+
+	return entries, err
+}
+
+// Renders every file in the template directory `root` into `outputDir`.
+// File and directory names are expanded as templates too. Files for
+// which any part of the name expands to an empty string are skipped.
+// Outputs get the same mode as their templates.
+func RenderDirectory(
+	fsys fs.FS,
+	engine *templates.Engine,
+	index int,
+	root string,
+	suffix string,
+	outputDir string,
+	data map[string]any,
+) ([]RenderedPair, error) {
+	entries, err := ListDirectory(fsys, root, suffix)
+
+	if err != nil {
+		return nil, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to list template directory for pair #%d: %w", index, err),
+		)
+	}
+
+	var rendered []RenderedPair
+
+	for _, entry := range entries {
+		start := time.Now()
+		name, err := expandEntryName(engine, entry.Name, data)
+
+		if err != nil {
+			return nil, MakeRenderError(
+				templateErrorKind(err),
+				fmt.Errorf("Failed to expand name of '%s' for pair #%d: %w", entry.Path, index, err),
+			)
+		}
+
+		if name == "" {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Path)
+
+		if err != nil {
+			return nil, MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to read template file '%s' for pair #%d: %w", entry.Path, index, err),
+			)
+		}
+
+		stat, err := fs.Stat(fsys, entry.Path)
+
+		if err != nil {
+			return nil, MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to read template file '%s' for pair #%d: %w", entry.Path, index, err),
+			)
+		}
+
+		var usesRegions bool
+
+		if !entry.Verbatim {
+			t, err := templates.TrackRegions(engine.GetTemplate(), &usesRegions).
+				Parse(string(content))
+
+			if err != nil {
+				return nil, MakeRenderError(
+					TemplateParseErrorKind,
+					fmt.Errorf("Failed to parse template '%s' for pair #%d: %w", entry.Path, index, err),
+				)
+			}
+
+			var buffer bytes.Buffer
+			err = t.Execute(&buffer, templates.PrepareData(data))
+
+			if err != nil {
+				return nil, MakeRenderError(
+					TemplateExecutionErrorKind,
+					fmt.Errorf("Failed to execute template '%s' for pair #%d: %w", entry.Path, index, err),
+				)
+			}
+
+			content = buffer.Bytes()
+		}
+
+		rendered = append(rendered, RenderedPair{
+			Index:        index,
+			TemplatePath: entry.Path,
+			OutputPath:   path.Join(outputDir, name),
+			Content:      content,
+			Mode:         stat.Mode().Perm(),
+			UsesRegions:  usesRegions,
+			Duration:     time.Since(start),
+		})
+	}
+
+	return rendered, nil
+}
+
+// Expands each segment of `name` separately. Returns an empty string
+// if any of them expands to nothing.
+func expandEntryName(
+	engine *templates.Engine,
+	name string,
+	data map[string]any,
+) (string, error) {
+	segments := strings.Split(name, "/")
+
+	for i, segment := range segments {
+		expanded, err := engine.ExpandString(name, segment, data)
+
+		if err != nil {
+			return "", err
+		}
+
+		if expanded == "" {
+			return "", nil
+		}
+
+		segments[i] = expanded
+	}
+
+	return path.Join(segments...), nil
+}
+
+// Patterns from an ignore file. Patterns containing a slash are
+// matched against the whole path relative to the template directory,
+// and the others against the base name of each file and directory.
+// Patterns ending with a slash only match directories. Empty lines and
+// lines starting with `#` are skipped.
+type ignorePatterns []string
+
+func readIgnoreFile(fsys fs.FS, p string) (ignorePatterns, error) {
+	file, err := fsys.Open(p)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var patterns ignorePatterns
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		_, err := path.Match(line, "")
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern '%s' in '%s': %w", line, p, err)
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+func (patterns ignorePatterns) Matches(rel string, isDir bool) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		subject := path.Base(rel)
+
+		if strings.Contains(pattern, "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+			subject = rel
+		}
+
+		// Already validated.
+		matched, _ := path.Match(pattern, subject)
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
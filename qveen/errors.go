@@ -0,0 +1,108 @@
+package qveen
+
+import (
+	"errors"
+
+	"github.com/charmbracelet/huh"
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/templates"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Broad category of a failure, mostly so that scripts can tell them
+// apart by the exit code.
+type ErrorKind uint
+
+const (
+	ParamsParseErrorKind ErrorKind = iota + 1
+	MetaValidationErrorKind
+	PromptErrorKind
+	TemplateParseErrorKind
+	TemplateExecutionErrorKind
+	IOErrorKind
+	AbortedErrorKind
+	HookErrorKind
+)
+
+// Documented in the README. Do not change them.
+func (k ErrorKind) ExitCode() int {
+	switch k {
+	case ParamsParseErrorKind:
+		return 2
+	case MetaValidationErrorKind:
+		return 3
+	case PromptErrorKind:
+		return 4
+	case TemplateParseErrorKind:
+		return 5
+	case TemplateExecutionErrorKind:
+		return 6
+	case IOErrorKind:
+		return 7
+	case AbortedErrorKind:
+		return 8
+	case HookErrorKind:
+		return 9
+	}
+
+	return 1
+}
+
+type RenderError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// If `err` comes from the user refusing to go on, the kind will be
+// `AbortedErrorKind` regardless of `kind`.
+func MakeRenderError(kind ErrorKind, err error) RenderError {
+	if errors.Is(err, utils.ErrAborted) || errors.Is(err, huh.ErrUserAborted) {
+		kind = AbortedErrorKind
+	}
+
+	return RenderError{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+func (e RenderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RenderError) Unwrap() error {
+	return e.Err
+}
+
+// For errors coming from expanding strings as templates, which may
+// fail either to parse or to execute.
+func templateErrorKind(err error) ErrorKind {
+	if templates.IsExecError(err) {
+		return TemplateExecutionErrorKind
+	}
+
+	return TemplateParseErrorKind
+}
+
+// For errors coming from parsing a parameter file, which may be either
+// syntax errors or errors in the contents of `meta`.
+func paramsErrorKind(err error) ErrorKind {
+	var paramErr params.ParamError
+
+	if errors.As(err, &paramErr) {
+		return MetaValidationErrorKind
+	}
+
+	return ParamsParseErrorKind
+}
+
+// Exit code for any error returned by the application.
+func ExitCode(err error) int {
+	var renderErr RenderError
+
+	if errors.As(err, &renderErr) {
+		return renderErr.Kind.ExitCode()
+	}
+
+	return 1
+}
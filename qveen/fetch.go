@@ -0,0 +1,286 @@
+package qveen
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Gets remote files.
+type Fetcher interface {
+	// `url` starts with `http://` or `https://`. The caller closes the
+	// result.
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// Implemented by fetchers that can do better when they know what the
+// contents must hash to, such as not fetching at all. Whatever they
+// return is checked either way.
+type PinnedFetcher interface {
+	Fetcher
+
+	// `sum` is a lowercase hexadecimal SHA-256 hash.
+	FetchPinned(ctx context.Context, url string, sum string) (io.ReadCloser, error)
+}
+
+// Waited before the first retry if `HTTPFetcher.RetryDelay` is not set.
+const DefaultRetryDelay = 500 * time.Millisecond
+
+var ErrResponseTooLarge = errors.New("Response is too large")
+
+type HTTPFetcher struct {
+	// `http.DefaultClient` if nil. See `NewHTTPClient` for timeouts and
+	// certificates.
+	Client *http.Client
+
+	// Added to every request to a host, by host name. A port may be
+	// included to apply to that port only. Checked again for each
+	// redirect, so that they are never sent to other hosts.
+	Headers map[string]http.Header
+
+	// How many more times to try when the server responds with a 5xx
+	// status or cannot be reached. The wait doubles after each try,
+	// starting at `RetryDelay`.
+	Retries    int
+	RetryDelay time.Duration
+
+	// Responses with more bytes than this are errors. No limit if 0.
+	MaxSize int64
+
+	// If set, fetched files are kept in it, revalidated with their
+	// `ETag` or `Last-Modified` on later fetches, and not fetched again
+	// at all when pinned.
+	Cache *Cache
+
+	// If set, nothing is fetched, and only files in `Cache` are found.
+	Offline bool
+}
+
+// A client that gives up on requests taking longer than `timeout`,
+// unless 0, and that trusts the certificates in the PEM file at
+// `caBundle` besides those of the system, if set.
+func NewHTTPClient(timeout time.Duration, caBundle string) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+
+	if caBundle == "" {
+		return client, nil
+	}
+
+	pem, err := os.ReadFile(caBundle)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No certificates found in CA bundle '%s'", caBundle)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	client.Transport = transport
+
+	return client, nil
+}
+
+func (f HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	var entry cacheEntry
+	cached := false
+
+	if f.Cache != nil {
+		entry, cached = f.Cache.lookup(url)
+	}
+
+	if f.Offline {
+		if !cached {
+			return nil, errors.New("Not in the cache, and cannot be fetched offline")
+		}
+
+		return f.Cache.Open(entry.Sha256)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	if cached && entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+
+	resp, content, err := f.do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		return f.Cache.Open(entry.Sha256)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Got status %s", resp.Status)
+	}
+
+	if f.Cache != nil {
+		err = f.Cache.store(cacheEntry{
+			Url:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}, content)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to cache: %w", err)
+		}
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Uses the cached contents with that hash, wherever they came from,
+// without asking the server.
+func (f HTTPFetcher) FetchPinned(ctx context.Context, url string, sum string) (io.ReadCloser, error) {
+	if f.Cache != nil {
+		reader, err := f.Cache.Open(sum)
+
+		if err == nil {
+			return reader, nil
+		}
+	}
+
+	return f.Fetch(ctx, url)
+}
+
+// Sends `req` until it gets an answer other than a 5xx status, or runs
+// out of retries, and reads the whole body.
+func (f HTTPFetcher) do(req *http.Request) (*http.Response, []byte, error) {
+	client := f.client()
+
+	delay := f.RetryDelay
+
+	if delay == 0 {
+		delay = DefaultRetryDelay
+	}
+
+	for try := 0; ; try++ {
+		resp, content, err := f.doOnce(client, req)
+
+		retry := (err != nil && !errors.Is(err, ErrResponseTooLarge)) ||
+			(err == nil && resp.StatusCode >= 500)
+
+		if !retry || try >= f.Retries || req.Context().Err() != nil {
+			return resp, content, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, nil, req.Context().Err()
+		}
+
+		delay *= 2
+	}
+}
+
+// `Client`, with `Headers` added by its transport rather than to the
+// request, since the client copies those to wherever it is redirected.
+func (f HTTPFetcher) client() *http.Client {
+	client := f.Client
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if len(f.Headers) == 0 {
+		return client
+	}
+
+	transport := client.Transport
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	withHeaders := *client
+	withHeaders.Transport = hostHeaders{transport, f.Headers}
+	return &withHeaders
+}
+
+// Adds the headers for the host of each request it sends.
+type hostHeaders struct {
+	transport http.RoundTripper
+	headers   map[string]http.Header
+}
+
+func (t hostHeaders) RoundTrip(req *http.Request) (*http.Response, error) {
+	hosts := []string{req.URL.Hostname(), req.URL.Host}
+
+	if t.headers[hosts[0]] == nil && t.headers[hosts[1]] == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	// Requests must not be changed by transports.
+	req = req.Clone(req.Context())
+
+	for _, host := range hosts {
+		for key, values := range t.headers[host] {
+			req.Header[key] = values
+		}
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+func (f HTTPFetcher) doOnce(client *http.Client, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if f.MaxSize > 0 && resp.ContentLength > f.MaxSize {
+		return nil, nil, f.tooLargeError()
+	}
+
+	var body io.Reader = resp.Body
+
+	if f.MaxSize > 0 {
+		body = io.LimitReader(body, f.MaxSize+1)
+	}
+
+	content, err := io.ReadAll(body)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if f.MaxSize > 0 && int64(len(content)) > f.MaxSize {
+		return nil, nil, f.tooLargeError()
+	}
+
+	return resp, content, nil
+}
+
+func (f HTTPFetcher) tooLargeError() error {
+	return fmt.Errorf("%w, over the limit of %d bytes", ErrResponseTooLarge, f.MaxSize)
+}
@@ -0,0 +1,149 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fetchString(t *testing.T, fetcher HTTPFetcher, url string) (string, error) {
+	t.Helper()
+	reader, err := fetcher.Fetch(context.Background(), url)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	return string(content), err
+}
+
+func TestFetchRetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		io.WriteString(w, "content")
+	}))
+
+	defer server.Close()
+
+	fetcher := HTTPFetcher{Retries: 2, RetryDelay: time.Millisecond}
+	content, err := fetchString(t, fetcher, server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if content != "content" {
+		t.Errorf("Expected %q, got %q", "content", content)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestFetchGivesUpAfterRetries(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	defer server.Close()
+
+	fetcher := HTTPFetcher{Retries: 2, RetryDelay: time.Millisecond}
+	_, err := fetchString(t, fetcher, server.URL)
+
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestFetchMaxSize(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		// Without a length upfront, so that it is only found out while
+		// reading.
+		if r.URL.Path == "/streamed" {
+			w.(http.Flusher).Flush()
+		}
+
+		io.WriteString(w, "more than ten bytes")
+	}))
+
+	defer server.Close()
+
+	fetcher := HTTPFetcher{MaxSize: 10, Retries: 2, RetryDelay: time.Millisecond}
+
+	for _, path := range []string{"/sized", "/streamed"} {
+		requests.Store(0)
+		_, err := fetchString(t, fetcher, server.URL+path)
+
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("Expected %s to be too large, got %v", path, err)
+		}
+
+		if requests.Load() != 1 {
+			t.Errorf("Expected %s not to be retried, got %d requests", path, requests.Load())
+		}
+	}
+}
+
+func TestFetchHostHeaders(t *testing.T) {
+	var otherAuth atomic.Value
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuth.Store(r.Header.Get("Authorization"))
+		io.WriteString(w, "content")
+	}))
+
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+
+	defer server.Close()
+
+	fetcher := HTTPFetcher{
+		Headers: map[string]http.Header{
+			strings.TrimPrefix(server.URL, "http://"): {"Authorization": {"token secret"}},
+		},
+	}
+
+	_, err := fetchString(t, fetcher, server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := otherAuth.Load(); auth != "" {
+		t.Errorf("Expected no headers to be sent to the other host, got %q", auth)
+	}
+}
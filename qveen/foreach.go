@@ -0,0 +1,108 @@
+package qveen
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/templates"
+)
+
+// A pair together with the data to render it with. Pairs with
+// `foreach` make one per item.
+type pairInstance struct {
+	Index int
+	Pair  params.ParamsPair
+	Data  map[string]any
+
+	// Whether it comes from a `foreach`, and thus has its own data.
+	IsItem bool
+}
+
+func instantiatePairs(
+	engine *templates.Engine,
+	p params.Params,
+) ([]pairInstance, error) {
+	instances := make([]pairInstance, 0, len(p.Pairs))
+
+	for i, pair := range p.Pairs {
+		if pair.Foreach == "" {
+			instances = append(instances, pairInstance{
+				Index: i,
+				Pair:  pair,
+				Data:  p.Data,
+			})
+
+			continue
+		}
+
+		items, err := ForeachItems(pair.Foreach, p.Data)
+
+		if err != nil {
+			return nil, MakeRenderError(
+				MetaValidationErrorKind,
+				fmt.Errorf("Failed to evaluate `foreach` for pair #%d: %w", i, err),
+			)
+		}
+
+		for j, item := range items {
+			data := maps.Clone(p.Data)
+			data["item"] = item
+			data["index"] = j
+
+			instance := pairInstance{
+				Index:  i,
+				Pair:   pair,
+				Data:   data,
+				IsItem: true,
+			}
+
+			err := instance.Pair.Expand(engine, data)
+
+			if err != nil {
+				return nil, MakeRenderError(
+					templateErrorKind(err),
+					fmt.Errorf("Failed to expand pair #%d for item #%d: %w", i, j, err),
+				)
+			}
+
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances, nil
+}
+
+// Paths such as `api.endpoints`, which are made jq expressions by
+// prefixing them with `.`.
+var foreachPathRegexp = regexp.MustCompile(`^[A-Za-z_][\w.]*$`)
+
+// Items yielded by `expr`, which is either a path such as
+// `api.endpoints` or a jq expression. If it yields a single array, its
+// elements are the items, and if it yields a single null, there are
+// none.
+func ForeachItems(expr string, data map[string]any) ([]any, error) {
+	query := expr
+
+	if foreachPathRegexp.MatchString(query) {
+		query = "." + query
+	}
+
+	results, err := templates.RunJq(query, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 1 {
+		switch result := results[0].(type) {
+		case []any:
+			return result, nil
+		case nil:
+			return nil, nil
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package qveen
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestForeachItems(t *testing.T) {
+	data := map[string]any{
+		"api": map[string]any{
+			"endpoints": []any{"a", "b"},
+		},
+		"none": nil,
+	}
+
+	tests := map[string][]any{
+		"api.endpoints":                     {"a", "b"},
+		".api.endpoints":                    {"a", "b"},
+		".api.endpoints[]":                  {"a", "b"},
+		"[.api.endpoints[] | ascii_upcase]": {"A", "B"},
+		"none":                              nil,
+	}
+
+	for expr, expected := range tests {
+		items, err := ForeachItems(expr, data)
+
+		if err != nil {
+			t.Errorf("Failed to run %q: %v", expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(items, expected) {
+			t.Errorf("Expected %q to yield %v, got %v", expr, expected, items)
+		}
+	}
+}
+
+func TestRenderForeach(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+tables = [{ name = "users" }, { name = "orders", skip = true }, { name = "items" }]
+
+[[meta.pairs]]
+template = "model.tmpl"
+output = "models/{{.item.name}}.txt"
+foreach = "[.tables[] | select(.skip | not)]"
+`),
+		"model.tmpl": mapFile("{{.index}} {{.item.name}}\n"),
+	}
+
+	output := MemFS{}
+	_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, output, "models/users.txt", "0 users\n")
+	assertFile(t, output, "models/items.txt", "1 items\n")
+
+	if _, ok := output["models/orders.txt"]; ok {
+		t.Error("Expected 'models/orders.txt' not to be written")
+	}
+}
@@ -0,0 +1,180 @@
+package qveen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/veigaribo/qveen/params"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats for output files by extension, for `params.OutputFormatAuto`.
+var outputFormatExtensions = map[string]string{
+	".go":   params.OutputFormatGo,
+	".json": params.OutputFormatJson,
+	".yaml": params.OutputFormatYaml,
+	".yml":  params.OutputFormatYaml,
+	".toml": params.OutputFormatToml,
+}
+
+// Reformats `content` as `outputFormat`. With `params.OutputFormatAuto`,
+// the format is chosen by the extension of `outputPath`, and unknown
+// extensions are left alone. Syntax errors come with the offending line.
+func FormatOutput(outputFormat, outputPath string, content []byte) ([]byte, error) {
+	if outputFormat == params.OutputFormatAuto {
+		outputFormat = outputFormatExtensions[path.Ext(outputPath)]
+	}
+
+	var result []byte
+	var err error
+	var line int
+
+	switch outputFormat {
+	case params.OutputFormatGo:
+		result, err = format.Source(content)
+
+		var errs scanner.ErrorList
+
+		if errors.As(err, &errs) && len(errs) > 0 {
+			line = errs[0].Pos.Line
+		}
+	case params.OutputFormatJson:
+		// Whitespace after the value would be kept as it is, so it is
+		// replaced with a single line break.
+		var buffer bytes.Buffer
+		err = json.Indent(&buffer, bytes.TrimRight(content, " \t\r\n"), "", "  ")
+
+		var syntaxErr *json.SyntaxError
+
+		if errors.As(err, &syntaxErr) {
+			line = lineAt(content, int(syntaxErr.Offset))
+		}
+
+		if err == nil {
+			buffer.WriteByte('\n')
+			result = buffer.Bytes()
+		}
+	case params.OutputFormatYaml:
+		result, err = formatYaml(content)
+		line = yamlErrorLine(err)
+	case params.OutputFormatToml:
+		result, err = formatToml(content)
+
+		var decodeErr *toml.DecodeError
+
+		if errors.As(err, &decodeErr) {
+			line, _ = decodeErr.Position()
+		}
+	default:
+		return content, nil
+	}
+
+	if err != nil {
+		return nil, withLine(err, content, line)
+	}
+
+	return result, nil
+}
+
+// Formats every document, keeping comments.
+func formatYaml(content []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = encoder.Encode(&node)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := encoder.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Comments are lost and keys are sorted, since the library does not
+// keep them around.
+func formatToml(content []byte) ([]byte, error) {
+	var data map[string]any
+	err := toml.Unmarshal(content, &data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	encoder := toml.NewEncoder(&buffer)
+	encoder.SetIndentTables(false)
+
+	err = encoder.Encode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// The YAML library only tells the line in the message.
+func yamlErrorLine(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	match := yamlLineRegexp.FindStringSubmatch(err.Error())
+
+	if match == nil {
+		return 0
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
+
+// 1-based line number of the byte at `offset`.
+func lineAt(content []byte, offset int) int {
+	offset = min(offset, len(content))
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+// Adds line `line` of `content` to `err`, if it exists.
+func withLine(err error, content []byte, line int) error {
+	lines := bytes.Split(content, []byte("\n"))
+
+	if line < 1 || line > len(lines) {
+		return err
+	}
+
+	return fmt.Errorf("%w\n%d | %s", err, line, lines[line-1])
+}
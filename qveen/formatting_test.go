@@ -0,0 +1,51 @@
+package qveen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veigaribo/qveen/params"
+)
+
+func TestFormatJson(t *testing.T) {
+	content := []byte("{\"a\": [1,\n2]}\n\n")
+	result, err := FormatOutput(params.OutputFormatJson, "data", content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"
+
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestFormatToml(t *testing.T) {
+	content := []byte("b = 2\na   =   1\n[table]\n  c = \"x\"\n")
+	result, err := FormatOutput(params.OutputFormatAuto, "config.toml", content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "a = 1\nb = 2\n\n[table]\nc = 'x'\n"
+
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestFormatTomlError(t *testing.T) {
+	content := []byte("a = 1\nb = = 2\n")
+	_, err := FormatOutput(params.OutputFormatToml, "config", content)
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if !strings.HasSuffix(err.Error(), "\n2 | b = = 2") {
+		t.Errorf("Expected the error to show line 2, got %q", err)
+	}
+}
@@ -0,0 +1,163 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing/fstest"
+	"time"
+
+	"github.com/veigaribo/qveen/utils"
+)
+
+// The local file system. Unlike with `os.DirFS`, paths are given to the
+// operating system as they are, so absolute paths and paths outside of
+// the current directory work too. Writes happen all at once, even if
+// interrupted.
+type DiskFS struct{}
+
+func (DiskFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (DiskFS) WriteFiles(ctx context.Context, files []OutputFile) error {
+	var tx utils.Transaction
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			tx.Rollback()
+			return utils.ErrAborted
+		}
+
+		err := tx.StageMode(file.Path, file.Content, file.Mode)
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		tx.Rollback()
+		return utils.ErrAborted
+	}
+
+	return tx.Commit()
+}
+
+func (DiskFS) RemoveFiles(ctx context.Context, paths []string, root string) error {
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return utils.ErrAborted
+		}
+
+		err := os.Remove(path)
+
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		removeEmptyDirs(filepath.Dir(path), root)
+	}
+
+	return nil
+}
+
+// Removes `dir` and its parents while they are empty, stopping at
+// `root`.
+func removeEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+
+	for dir = filepath.Clean(dir); dir != root; dir = filepath.Dir(dir) {
+		rel, err := filepath.Rel(root, dir)
+
+		// Not inside `root`.
+		if err != nil || rel == ".." ||
+			strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+
+		// Fails if not empty.
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// Keeps files in memory, by clean slash-separated path. Useful for
+// tests, or for getting results without touching the disk.
+type MemFS fstest.MapFS
+
+func (m MemFS) Open(name string) (fs.File, error) {
+	return fstest.MapFS(m).Open(name)
+}
+
+func (m MemFS) WriteFiles(ctx context.Context, files []OutputFile) error {
+	if ctx.Err() != nil {
+		return utils.ErrAborted
+	}
+
+	for _, file := range files {
+		name := fsPath(file.Path)
+
+		if !fs.ValidPath(name) {
+			return &fs.PathError{Op: "write", Path: file.Path, Err: fs.ErrInvalid}
+		}
+	}
+
+	for _, file := range files {
+		name := fsPath(file.Path)
+		mode := file.Mode
+
+		if mode == 0 {
+			mode = 0666
+
+			if existing, ok := m[name]; ok {
+				mode = existing.Mode
+			}
+		}
+
+		m[name] = &fstest.MapFile{
+			Data:    slices.Clone(file.Content),
+			Mode:    mode,
+			ModTime: time.Now(),
+		}
+	}
+
+	return nil
+}
+
+func (m MemFS) RemoveFiles(ctx context.Context, paths []string, root string) error {
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return utils.ErrAborted
+		}
+
+		// Directories only exist as long as there are files in them.
+		delete(m, fsPath(path))
+	}
+
+	return nil
+}
+
+// Whether there is a directory at `name` in `fsys`. URLs and `-` never
+// are.
+func isDir(fsys fs.FS, name string) bool {
+	if !utils.IsLocal(name) {
+		return false
+	}
+
+	stat, err := fs.Stat(fsys, fsPath(name))
+	return err == nil && stat.IsDir()
+}
+
+// Paths given to an `fs.FS` are expected to be clean and
+// slash-separated.
+func fsPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
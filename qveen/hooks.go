@@ -0,0 +1,99 @@
+package qveen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/templates"
+)
+
+// Runs each command in `commands` with `sh`, stopping at the first one
+// that fails. Commands and environment values are expanded as templates
+// with `data`, plus `files`. Does nothing if there are no files, since
+// then there was nothing to write.
+func (renderer *Renderer) runHooks(
+	ctx context.Context,
+	engine *templates.Engine,
+	name string,
+	commands []string,
+	hooks params.ParamsHooks,
+	data map[string]any,
+	files []string,
+) error {
+	if len(commands) == 0 || len(files) == 0 {
+		return nil
+	}
+
+	hookData := maps.Clone(data)
+	filesData := make([]any, 0, len(files))
+
+	for _, file := range files {
+		filesData = append(filesData, file)
+	}
+
+	hookData["files"] = filesData
+
+	env := os.Environ()
+
+	for key, value := range hooks.Env {
+		expanded, err := engine.ExpandString(
+			fmt.Sprintf("%s env %s", name, key),
+			value,
+			hookData,
+		)
+
+		if err != nil {
+			return MakeRenderError(
+				templateErrorKind(err),
+				fmt.Errorf("Failed to expand environment variable '%s' for %s hooks: %w", key, name, err),
+			)
+		}
+
+		env = append(env, key+"="+expanded)
+	}
+
+	for i, command := range commands {
+		expanded, err := engine.ExpandString(
+			fmt.Sprintf("%s hook #%d", name, i),
+			command,
+			hookData,
+		)
+
+		if err != nil {
+			return MakeRenderError(
+				templateErrorKind(err),
+				fmt.Errorf("Failed to expand %s hook #%d: %w", name, i, err),
+			)
+		}
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", expanded)
+		cmd.Dir = hooks.Dir.Resolve()
+		cmd.Env = env
+
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+
+		err = cmd.Run()
+
+		if err != nil {
+			if output.Len() > 0 {
+				err = fmt.Errorf("%w\n%s", err, bytes.TrimRight(output.Bytes(), "\n"))
+			}
+
+			return MakeRenderError(
+				HookErrorKind,
+				fmt.Errorf("Hook `%s` failed: %w", expanded, err),
+			)
+		}
+
+		renderer.opts.Stderr.Write(output.Bytes())
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderIfExists(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected string
+		kind     ErrorKind
+	}{
+		{"overwrite", "new\n", 0},
+		{"skip", "old\n", 0},
+		{"if_changed", "new\n", 0},
+		{"fail", "old\n", IOErrorKind},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			source := fstest.MapFS{
+				"params.toml": mapFile(`
+[[meta.pairs]]
+template = "a.tmpl"
+output = "a.txt"
+if_exists = "` + test.policy + `"
+`),
+				"a.tmpl": mapFile("new\n"),
+			}
+
+			output := MemFS{
+				"a.txt": mapFile("old\n"),
+			}
+
+			_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+			var renderErr RenderError
+
+			switch {
+			case test.kind == 0 && err != nil:
+				t.Fatal(err)
+			case test.kind != 0 && !errors.As(err, &renderErr):
+				t.Fatalf("Expected an error of kind %d, got %v", test.kind, err)
+			case test.kind != 0 && renderErr.Kind != test.kind:
+				t.Fatalf("Expected an error of kind %d, got %d: %v", test.kind, renderErr.Kind, err)
+			}
+
+			assertFile(t, output, "a.txt", test.expected)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package qveen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/veigaribo/qveen/params"
+)
+
+// A `params.ParamsInject` with its patterns compiled.
+type Injection struct {
+	Before *regexp.Regexp
+	After  *regexp.Regexp
+	At     string
+	SkipIf *regexp.Regexp
+}
+
+// Patterns are compiled in multi-line mode, so that `^` and `$` match
+// at line boundaries.
+func CompileInjection(inject params.ParamsInject) (Injection, error) {
+	injection := Injection{At: inject.At}
+
+	patterns := []struct {
+		key     string
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{"before", inject.Before, &injection.Before},
+		{"after", inject.After, &injection.After},
+		{"skip_if", inject.SkipIf, &injection.SkipIf},
+	}
+
+	for _, p := range patterns {
+		if p.pattern == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("(?m)" + p.pattern)
+
+		if err != nil {
+			return injection, fmt.Errorf("Invalid `%s` pattern: %w", p.key, err)
+		}
+
+		*p.target = re
+	}
+
+	return injection, nil
+}
+
+// Inserts `snippet` into `existing` as whole lines. Returns `existing`
+// untouched if it matches `SkipIf`.
+func (inj Injection) Apply(existing, snippet []byte) ([]byte, error) {
+	if inj.SkipIf != nil && inj.SkipIf.Match(existing) {
+		return existing, nil
+	}
+
+	if len(snippet) > 0 && !bytes.HasSuffix(snippet, []byte("\n")) {
+		snippet = append(snippet, '\n')
+	}
+
+	var at int
+
+	switch {
+	case inj.Before != nil:
+		loc := inj.Before.FindIndex(existing)
+
+		if loc == nil {
+			return nil, fmt.Errorf("Pattern `%s` not found", inj.Before)
+		}
+
+		// Start of the line.
+		at = bytes.LastIndexByte(existing[:loc[0]], '\n') + 1
+	case inj.After != nil:
+		loc := inj.After.FindIndex(existing)
+
+		if loc == nil {
+			return nil, fmt.Errorf("Pattern `%s` not found", inj.After)
+		}
+
+		// End of the line, unless the match already includes it.
+		if loc[1] > loc[0] && existing[loc[1]-1] == '\n' {
+			at = loc[1]
+		} else if i := bytes.IndexByte(existing[loc[1]:], '\n'); i != -1 {
+			at = loc[1] + i + 1
+		} else {
+			at = len(existing)
+		}
+	case inj.At == "top":
+		at = 0
+	default:
+		at = len(existing)
+	}
+
+	var result bytes.Buffer
+	result.Grow(len(existing) + len(snippet) + 1)
+	result.Write(existing[:at])
+
+	if at > 0 && existing[at-1] != '\n' {
+		result.WriteByte('\n')
+	}
+
+	result.Write(snippet)
+	result.Write(existing[at:])
+
+	return result.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package qveen
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/veigaribo/qveen/params"
+)
+
+func TestInjectionApply(t *testing.T) {
+	existing := "a\nb\nc"
+
+	tests := map[string]struct {
+		inject   params.ParamsInject
+		expected string
+	}{
+		"before":          {params.ParamsInject{Before: "^b"}, "a\nnew\nb\nc"},
+		"after":           {params.ParamsInject{After: "^b"}, "a\nb\nnew\nc"},
+		"after last line": {params.ParamsInject{After: "c"}, "a\nb\nc\nnew\n"},
+		"top":             {params.ParamsInject{At: "top"}, "new\na\nb\nc"},
+		"bottom":          {params.ParamsInject{At: "bottom"}, "a\nb\nc\nnew\n"},
+		"skip_if":         {params.ParamsInject{After: "^b", SkipIf: "^c$"}, existing},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			injection, err := CompileInjection(test.inject)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := injection.Apply([]byte(existing), []byte("new"))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(result) != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestInjectionApplyMissingAnchor(t *testing.T) {
+	injection, err := CompileInjection(params.ParamsInject{Before: "^z"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = injection.Apply([]byte("a\n"), []byte("new\n"))
+
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestRenderInjectIsIdempotent(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[[meta.pairs]]
+template = "route.tmpl"
+output = "routes.go"
+inject = { after = "^func routes", skip_if = "/users" }
+`),
+		"route.tmpl": mapFile("\tget(\"/users\")\n"),
+	}
+
+	output := MemFS{
+		"routes.go": mapFile("func routes() {\n}\n"),
+	}
+
+	expected := "func routes() {\n\tget(\"/users\")\n}\n"
+
+	for range 2 {
+		_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertFile(t, output, "routes.go", expected)
+	}
+}
@@ -0,0 +1,239 @@
+package qveen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/templates"
+)
+
+// Everything needed to render a pair instance on its own, so that they
+// may be rendered concurrently.
+type renderJob struct {
+	pairInstance
+
+	// Already resolved, flag included.
+	TemplatePath string
+
+	// What the template must hash to, if set.
+	TemplateSha256 string
+
+	// `--output`, expanded for this instance.
+	OutputPathFlag string
+
+	// For error messages.
+	IsSinglePair bool
+
+	Engine *templates.Engine
+}
+
+func (job renderJob) IsSkipped() bool {
+	return job.Pair.When != nil && params.IsFalsy(*job.Pair.When)
+}
+
+// Loads, parses and executes the template. Directory templates give
+// many results, and skipped pairs give one without content. Remote
+// templates are fetched through `renderer`.
+func (job renderJob) Render(
+	ctx context.Context,
+	renderer *Renderer,
+) ([]RenderedPair, error) {
+	start := time.Now()
+	i, pair, data := job.Index, job.Pair, job.Data
+	templatePath := job.TemplatePath
+	isSinglePair := job.IsSinglePair
+
+	var outputLoc OutputLocation
+	outputLoc.Add(pair.Output.Resolve())
+	outputLoc.Add(job.OutputPathFlag)
+
+	if job.IsSkipped() {
+		// Only for display, so do not bother with errors.
+		outputPath, err := outputLoc.Path()
+
+		if err != nil {
+			outputPath, _ = outputLoc.Dir()
+		}
+
+		return []RenderedPair{{
+			Index:        i,
+			TemplatePath: templatePath,
+			OutputPath:   outputPath,
+			SkipReason:   fmt.Sprintf("`when` is %q", *pair.When),
+		}}, nil
+	}
+
+	mode, err := ParseMode(pair.Mode)
+
+	if err != nil {
+		return nil, pairError(MetaValidationErrorKind, isSinglePair, i, "parse mode", err)
+	}
+
+	if isDir(renderer.opts.Source, templatePath) {
+		if pair.Inject != nil {
+			return nil, MakeRenderError(
+				MetaValidationErrorKind,
+				fmt.Errorf("Cannot inject directory template '%s' for pair #%d", templatePath, i),
+			)
+		}
+
+		if job.TemplateSha256 != "" {
+			return nil, MakeRenderError(
+				MetaValidationErrorKind,
+				fmt.Errorf("Cannot pin directory template '%s' for pair #%d with `sha256`", templatePath, i),
+			)
+		}
+
+		outputDir, err := outputLoc.Dir()
+
+		if err != nil {
+			return nil, MakeRenderError(
+				MetaValidationErrorKind,
+				fmt.Errorf("Failed to generate output directory for pair #%d: %w", i, err),
+			)
+		}
+
+		files, err := RenderDirectory(
+			renderer.opts.Source,
+			job.Engine,
+			i,
+			templatePath,
+			pair.TemplateSuffix,
+			outputDir,
+			data,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range files {
+			files[j].IfExists = pair.IfExists
+			files[j].Format = pair.Format
+			files[j].PreserveMode = pair.PreserveMode
+
+			if mode != 0 {
+				files[j].Mode = mode
+			}
+		}
+
+		return files, nil
+	}
+
+	templateReader, err := renderer.openPinned(ctx, templatePath, job.TemplateSha256)
+
+	if err != nil {
+		return nil, pairError(IOErrorKind, isSinglePair, i, "open template file", err)
+	}
+
+	templateData, err := io.ReadAll(templateReader)
+	templateReader.Close()
+
+	if err != nil {
+		return nil, pairError(IOErrorKind, isSinglePair, i, "read template file", err)
+	}
+
+	if job.TemplateSha256 != "" {
+		err = checkSha256(templateData, job.TemplateSha256)
+
+		if err != nil {
+			return nil, pairError(IOErrorKind, isSinglePair, i, "verify template file", err)
+		}
+	}
+
+	var usesRegions bool
+	t, err := templates.TrackRegions(job.Engine.GetTemplate(), &usesRegions).
+		Parse(string(templateData))
+
+	if err != nil {
+		return nil, pairError(TemplateParseErrorKind, isSinglePair, i, "parse template", err)
+	}
+
+	outputPath, err := outputLoc.Path()
+
+	if err != nil {
+		return nil, pairError(MetaValidationErrorKind, isSinglePair, i, "generate output path", err)
+	}
+
+	var content bytes.Buffer
+	err = t.Execute(&content, templates.PrepareData(data))
+
+	if err != nil {
+		return nil, pairError(TemplateExecutionErrorKind, isSinglePair, i, "execute template", err)
+	}
+
+	return []RenderedPair{{
+		Index:        i,
+		TemplatePath: templatePath,
+		OutputPath:   outputPath,
+		Content:      content.Bytes(),
+		IfExists:     pair.IfExists,
+		Format:       pair.Format,
+		Mode:         mode,
+		PreserveMode: pair.PreserveMode,
+		UsesRegions:  usesRegions,
+		Duration:     time.Since(start),
+	}}, nil
+}
+
+// Renders `jobs` with up to `Options.Jobs` of them at a time. Results
+// are in the same order as `jobs`. If more than one fails, the error of
+// the first one is returned, and no more jobs are started after a
+// failure or once `ctx` is done.
+func (renderer *Renderer) renderAll(
+	ctx context.Context,
+	jobs []renderJob,
+) ([][]RenderedPair, error) {
+	results := make([][]RenderedPair, len(jobs))
+	errs := make([]error, len(jobs))
+
+	workers := max(1, min(renderer.opts.Jobs, len(jobs)))
+
+	var next atomic.Int64
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for !failed.Load() {
+				k := int(next.Add(1) - 1)
+
+				if k >= len(jobs) {
+					return
+				}
+
+				if ctx.Err() != nil {
+					errs[k] = MakeRenderError(AbortedErrorKind, ctx.Err())
+					failed.Store(true)
+					return
+				}
+
+				results[k], errs[k] = jobs[k].Render(ctx, renderer)
+
+				if errs[k] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,231 @@
+package qveen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Used when the manifest path given is a directory.
+const DefaultManifestName = ".qveen-manifest.json"
+
+type ManifestEntry struct {
+	Template string   `json:"template"`
+	Params   []string `json:"params"`
+	Sha256   string   `json:"sha256"`
+}
+
+// Keeps track of generated files, so that we can tell when they were
+// edited by hand and when they are no longer generated.
+//
+// Paths in it are relative to the directory containing it, so that it
+// does not matter from where Qveen is run.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+
+	path string
+
+	// Where the manifest and the files in it are.
+	fsys fs.FS
+}
+
+// A missing file is the same as an empty manifest.
+func LoadManifest(fsys fs.FS, path string) (*Manifest, error) {
+	if IsPrefix(path) || isDir(fsys, path) {
+		path = filepath.Join(path, DefaultManifestName)
+	}
+
+	manifest := Manifest{
+		Files: make(map[string]ManifestEntry),
+		path:  path,
+		fsys:  fsys,
+	}
+
+	content, err := fs.ReadFile(fsys, fsPath(path))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return &manifest, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &manifest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]ManifestEntry)
+	}
+
+	return &manifest, nil
+}
+
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// Makes `path` relative to the manifest. URLs and `-` are kept as they
+// are.
+func (m *Manifest) Key(path string) (string, error) {
+	if !utils.IsLocal(path) {
+		return path, nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(m.path))
+
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(dir, abs)
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// The inverse of `Key`.
+func (m *Manifest) Resolve(key string) string {
+	return filepath.Join(filepath.Dir(m.path), filepath.FromSlash(key))
+}
+
+func (m *Manifest) keys(paths []string) ([]string, error) {
+	keys := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		key, err := m.Key(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (m *Manifest) Lookup(outputPath string) (ManifestEntry, bool, error) {
+	key, err := m.Key(outputPath)
+
+	if err != nil {
+		return ManifestEntry{}, false, err
+	}
+
+	entry, ok := m.Files[key]
+	return entry, ok, nil
+}
+
+// Whether the file at `outputPath` is known to us, and if so, whether
+// it was changed since we generated it.
+func (m *Manifest) Check(outputPath string) (tracked, modified bool, err error) {
+	entry, tracked, err := m.Lookup(outputPath)
+
+	if err != nil || !tracked {
+		return tracked, false, err
+	}
+
+	content, err := fs.ReadFile(m.fsys, fsPath(outputPath))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, false, nil
+	}
+
+	if err != nil {
+		return true, false, err
+	}
+
+	return true, hashContent(content) != entry.Sha256, nil
+}
+
+func (m *Manifest) Record(r RenderedPair, paramsPaths []string) error {
+	key, err := m.Key(r.OutputPath)
+
+	if err != nil {
+		return err
+	}
+
+	template, err := m.Key(r.TemplatePath)
+
+	if err != nil {
+		return err
+	}
+
+	params, err := m.keys(paramsPaths)
+
+	if err != nil {
+		return err
+	}
+
+	m.Files[key] = ManifestEntry{
+		Template: template,
+		Params:   params,
+		Sha256:   hashContent(r.Content),
+	}
+
+	return nil
+}
+
+func (m *Manifest) Forget(key string) {
+	delete(m.Files, key)
+}
+
+// Keys of the files generated from the same parameter files that are
+// not among `produced`, which are output paths.
+func (m *Manifest) Stale(paramsPaths []string, produced []string) ([]string, error) {
+	params, err := m.keys(paramsPaths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	producedKeys, err := m.keys(produced)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+
+	for key, entry := range m.Files {
+		if slices.Equal(entry.Params, params) && !slices.Contains(producedKeys, key) {
+			stale = append(stale, key)
+		}
+	}
+
+	slices.Sort(stale)
+	return stale, nil
+}
+
+func (m *Manifest) Content() ([]byte, error) {
+	content, err := json.MarshalIndent(m, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+func hashContent(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
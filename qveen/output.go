@@ -0,0 +1,79 @@
+package qveen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Constructs a path with optional prefix.
+type OutputLocation struct {
+	Stem string
+	Leaf string
+}
+
+func IsPrefix(filename string) bool {
+	return strings.HasSuffix(filename, "/")
+}
+
+func (l *OutputLocation) Add(filename string) {
+	if len(filename) == 0 {
+		return
+	}
+
+	if IsPrefix(filename) {
+		l.Stem = filename
+	} else {
+		l.Leaf = filename
+	}
+}
+
+var ErrNoLeaf = errors.New("Output filename has only prefix")
+var ErrStdDir = errors.New("Cannot output a directory to stdout")
+var ErrNoDir = errors.New("Output directory is missing")
+
+func (l *OutputLocation) Path() (string, error) {
+	if l.Leaf == "" {
+		return "", ErrNoLeaf
+	}
+
+	if utils.IsStd(l.Leaf) {
+		return l.Leaf, nil
+	}
+
+	return path.Join(l.Stem, l.Leaf), nil
+}
+
+// Like `Path`, but for when the output is a directory, in which case
+// only the prefix is necessary.
+func (l *OutputLocation) Dir() (string, error) {
+	if utils.IsStd(l.Leaf) {
+		return "", ErrStdDir
+	}
+
+	if l.Stem == "" && l.Leaf == "" {
+		return "", ErrNoDir
+	}
+
+	return path.Join(l.Stem, l.Leaf), nil
+}
+
+// Parses octal permissions such as `0755`. An empty string gives 0.
+func ParseMode(mode string) (fs.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseUint(strings.TrimPrefix(mode, "0o"), 8, 32)
+
+	if err != nil || parsed == 0 || parsed > uint64(fs.ModePerm) {
+		return 0, fmt.Errorf("Invalid mode '%s', expected octal permissions such as 0755", mode)
+	}
+
+	return fs.FileMode(parsed), nil
+}
@@ -0,0 +1,139 @@
+package qveen
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := map[string]fs.FileMode{
+		"":      0,
+		"0755":  0755,
+		"644":   0644,
+		"0o600": 0600,
+	}
+
+	for mode, expected := range tests {
+		parsed, err := ParseMode(mode)
+
+		if err != nil || parsed != expected {
+			t.Errorf("Expected '%s' to be %o, got %o (%v)", mode, expected, parsed, err)
+		}
+	}
+
+	for _, mode := range []string{"0", "0999", "rwx", "01777"} {
+		_, err := ParseMode(mode)
+
+		if err == nil {
+			t.Errorf("Expected '%s' to be invalid", mode)
+		}
+	}
+}
+
+func TestRenderModes(t *testing.T) {
+	dir := t.TempDir()
+
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[[meta.pairs]]
+template = "script.tmpl"
+output = "new.sh"
+mode = "0755"
+
+[[meta.pairs]]
+template = "script.tmpl"
+output = "existing.sh"
+mode = "0755"
+
+[[meta.pairs]]
+template = "script.tmpl"
+output = "preserved.sh"
+mode = "0755"
+preserve_mode = true
+
+[[meta.pairs]]
+template = "script.tmpl"
+output = "created.sh"
+mode = "0750"
+preserve_mode = true
+`),
+		"script.tmpl": mapFile("#!/bin/sh\n"),
+	}
+
+	for _, name := range []string{"existing.sh", "preserved.sh"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0600)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Whatever the umask.
+		err = os.Chmod(filepath.Join(dir, name), 0600)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renderer := testRenderer(t, source, nil)
+	renderer.opts.Output = prefixedFS{dir}
+	renderer.opts.Overwrite = true
+	_, err := renderer.Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]fs.FileMode{
+		"new.sh":       0755,
+		"existing.sh":  0755,
+		"preserved.sh": 0600,
+		"created.sh":   0750,
+	}
+
+	for name, mode := range expected {
+		stat, err := os.Stat(filepath.Join(dir, name))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stat.Mode().Perm() != mode {
+			t.Errorf("Expected '%s' to have mode %o, got %o", name, mode, stat.Mode().Perm())
+		}
+	}
+}
+
+// A `DiskFS` rooted at `dir`, so that tests need not change directory.
+type prefixedFS struct {
+	dir string
+}
+
+func (p prefixedFS) Open(name string) (fs.File, error) {
+	return os.DirFS(p.dir).Open(name)
+}
+
+func (p prefixedFS) WriteFiles(ctx context.Context, files []OutputFile) error {
+	prefixed := make([]OutputFile, len(files))
+
+	for i, file := range files {
+		file.Path = filepath.Join(p.dir, file.Path)
+		prefixed[i] = file
+	}
+
+	return DiskFS{}.WriteFiles(ctx, prefixed)
+}
+
+func (p prefixedFS) RemoveFiles(ctx context.Context, paths []string, root string) error {
+	prefixed := make([]string, len(paths))
+
+	for i, path := range paths {
+		prefixed[i] = filepath.Join(p.dir, path)
+	}
+
+	return DiskFS{}.RemoveFiles(ctx, prefixed, filepath.Join(p.dir, root))
+}
@@ -0,0 +1,106 @@
+package qveen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/veigaribo/qveen/templates"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Parses the definitions in each of `paths` into `engine`, in order.
+// Local paths may be globs. Files matched more than once are only
+// loaded the first time.
+func (renderer *Renderer) loadPartials(
+	ctx context.Context,
+	engine *templates.Engine,
+	paths []string,
+) error {
+	loaded := make(map[string]bool)
+
+	for _, pattern := range paths {
+		matches, err := renderer.globPartials(pattern)
+
+		if err != nil {
+			return MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to find partials '%s': %w", pattern, err),
+			)
+		}
+
+		for _, partialPath := range matches {
+			key := partialPath
+
+			// So that `lib.tmpl`, `./lib.tmpl` and its absolute path
+			// are the same.
+			if utils.IsLocal(key) {
+				abs, err := filepath.Abs(key)
+
+				if err == nil {
+					key = abs
+				}
+			}
+
+			if loaded[key] {
+				continue
+			}
+
+			loaded[key] = true
+			renderer.opts.Session.AddSource(partialPath)
+
+			reader, err := renderer.open(ctx, partialPath)
+
+			if err != nil {
+				return MakeRenderError(
+					IOErrorKind,
+					fmt.Errorf("Failed to open partials '%s': %w", partialPath, err),
+				)
+			}
+
+			content, err := io.ReadAll(reader)
+			reader.Close()
+
+			if err != nil {
+				return MakeRenderError(
+					IOErrorKind,
+					fmt.Errorf("Failed to read partials '%s': %w", partialPath, err),
+				)
+			}
+
+			err = engine.AddPartials(partialPath, string(content))
+
+			if err != nil {
+				return MakeRenderError(
+					TemplateParseErrorKind,
+					fmt.Errorf("Failed to parse partials '%s': %w", partialPath, err),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// The files in `Options.Source` matching `pattern`, or `pattern` itself
+// if it is not a local glob.
+func (renderer *Renderer) globPartials(pattern string) ([]string, error) {
+	if !utils.IsLocal(pattern) || !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	matches, err := fs.Glob(renderer.opts.Source, fsPath(pattern))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("No files match '%s'", pattern)
+	}
+
+	return matches, nil
+}
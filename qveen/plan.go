@@ -0,0 +1,153 @@
+package qveen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/utils"
+)
+
+type PlanAction string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanOverwrite PlanAction = "overwrite"
+	PlanUnchanged PlanAction = "unchanged"
+	PlanStdout    PlanAction = "stdout"
+
+	// Overwrite with content inserted into the existing file.
+	PlanInject PlanAction = "inject"
+
+	// Leave the existing file alone, as requested by the pair.
+	PlanSkip PlanAction = "skip"
+)
+
+type PlanEntry struct {
+	Rendered RenderedPair
+	Action   PlanAction
+}
+
+// What would happen to each output if we were to write it.
+type Plan struct {
+	Entries []PlanEntry
+}
+
+// Compares with the current contents of `fsys`.
+func MakePlan(fsys fs.FS, rendered []RenderedPair) (Plan, error) {
+	var plan Plan
+
+	for _, r := range rendered {
+		if r.SkipReason != "" {
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Rendered: r,
+				Action:   PlanSkip,
+			})
+
+			continue
+		}
+
+		action, err := PlanActionFor(fsys, r.OutputPath, r.Content)
+
+		if err != nil {
+			return plan, err
+		}
+
+		if r.Injected && action == PlanOverwrite {
+			action = PlanInject
+		}
+
+		if r.IfExists == params.IfExistsSkip && !r.Injected &&
+			(action == PlanOverwrite || action == PlanUnchanged) {
+			action = PlanSkip
+		}
+
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Rendered: r,
+			Action:   action,
+		})
+	}
+
+	return plan, nil
+}
+
+// Compares `content` with whatever is currently at `path` in `fsys`.
+func PlanActionFor(fsys fs.FS, path string, content []byte) (PlanAction, error) {
+	if utils.IsStd(path) {
+		return PlanStdout, nil
+	}
+
+	if !utils.IsLocal(path) {
+		return "", fmt.Errorf("Tried to write to '%s', which is not a local file", path)
+	}
+
+	stat, err := fs.Stat(fsys, fsPath(path))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return PlanCreate, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if stat.IsDir() {
+		return "", fmt.Errorf("Destination '%s' already exists and is a directory", path)
+	}
+
+	existing, err := fs.ReadFile(fsys, fsPath(path))
+
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.Equal(existing, content) {
+		return PlanUnchanged, nil
+	}
+
+	return PlanOverwrite, nil
+}
+
+func (p Plan) Print(w io.Writer) {
+	// Align the action column.
+	actionWidth := 0
+
+	for _, entry := range p.Entries {
+		if len(entry.Action) > actionWidth {
+			actionWidth = len(entry.Action)
+		}
+	}
+
+	for _, entry := range p.Entries {
+		r := entry.Rendered
+
+		if r.SkipReason != "" {
+			fmt.Fprintf(
+				w,
+				"%d %-*s %s -> %s (%s)\n",
+				r.Index,
+				actionWidth,
+				entry.Action,
+				r.TemplatePath,
+				r.OutputPath,
+				r.SkipReason,
+			)
+
+			continue
+		}
+
+		fmt.Fprintf(
+			w,
+			"%d %-*s %s -> %s (%d bytes)\n",
+			r.Index,
+			actionWidth,
+			entry.Action,
+			r.TemplatePath,
+			r.OutputPath,
+			len(r.Content),
+		)
+	}
+}
@@ -0,0 +1,180 @@
+// Package qveen generates files from templates and parameter files.
+// It is everything the `qveen` command does, minus the command line.
+//
+//	renderer := qveen.NewRenderer(qveen.Options{Overwrite: true})
+//	report, err := renderer.Render(ctx, "params.toml")
+//
+// Renderers share no state, so any number of them may be used at the
+// same time, each with its own options.
+package qveen
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/veigaribo/qveen/prompts"
+)
+
+type Options struct {
+	// Format of the parameter files, `toml`, `yaml` or `json`. Guessed
+	// from the extension of each file if empty.
+	ParamsFormat string
+
+	// Overrides for the template and output of the pair, or only the
+	// output prefix if there are many pairs. May contain placeholders.
+	TemplatePath string
+	OutputPath   string
+
+	// Key to look for meta information, instead of `meta`.
+	MetaKey string
+
+	// Values for prompts, by name, so that they are not asked.
+	PromptValues map[string]string
+
+	// If set, existing files are overwritten without asking.
+	Overwrite bool
+
+	// If set, nothing is written and the plan is printed instead.
+	DryRun bool
+
+	// If set, changes to existing files are printed, and identical
+	// files are skipped.
+	Diff bool
+
+	// If set, `meta.hooks` and `meta.pairs[].post` are not run.
+	NoHooks bool
+
+	// How many pairs to render at the same time. Less than 2 means one
+	// at a time.
+	Jobs int
+
+	// Where to keep track of generated files. Overrides `meta.manifest`.
+	ManifestPath string
+
+	// Files, globs or URLs with template definitions, loaded after
+	// those in `meta.partials`.
+	Partials []string
+
+	// Optional. Gets filled with information about each run.
+	Session *RenderSession
+
+	// Override their counterparts in `meta`.
+	TemplateLeftDelim  string
+	TemplateRightDelim string
+	TemplateCase       string
+
+	// Asks the user for prompt values and confirmations. Uses the
+	// terminal if nil.
+	Prompter Prompter
+
+	// Gets parameter files and templates given as URLs. Uses
+	// `http.DefaultClient` if nil.
+	Fetcher Fetcher
+
+	// Where parameter files and templates are read from, unless they
+	// are URLs or `-`. The local file system if nil.
+	Source fs.FS
+
+	// Where outputs go, other than `-`. The local file system if nil.
+	// Hooks still run on the local file system regardless.
+	Output WritableFS
+
+	// Standard streams, for `-` paths, plans, diffs and messages.
+	// Default to those of the process.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Asks the user for whatever is needed along the way.
+type Prompter interface {
+	// Values for `ps`, by name. Those with a `Value` already should not
+	// be asked, only returned.
+	Prompt(ps []prompts.Prompt) (map[string]any, error)
+
+	// Whether the user agrees with `title`.
+	Confirm(title string) (bool, error)
+
+	// Like `Confirm`, but the user may also ask to see what would change
+	// in the file first.
+	ConfirmOverwrite(title string) (prompts.OverwriteChoice, error)
+}
+
+// Asks using forms in the terminal.
+type TerminalPrompter struct{}
+
+func (TerminalPrompter) Prompt(ps []prompts.Prompt) (map[string]any, error) {
+	return prompts.DoPrompt(ps)
+}
+
+func (TerminalPrompter) Confirm(title string) (bool, error) {
+	return prompts.AskConfirm(title), nil
+}
+
+func (TerminalPrompter) ConfirmOverwrite(title string) (prompts.OverwriteChoice, error) {
+	return prompts.AskOverwrite(title), nil
+}
+
+// A file to be written.
+type OutputFile struct {
+	Path    string
+	Content []byte
+
+	// Exact permissions to give the file. 0 means the default for new
+	// files and keeping the current ones for existing files.
+	Mode fs.FileMode
+}
+
+// A file system that generated files can be put into. Existing files
+// are read through it as well, to compare with, inject into and carry
+// regions over from. The manifest is kept in it too.
+type WritableFS interface {
+	fs.FS
+
+	// Writes all of `files`, or none of them if it fails or `ctx` is
+	// done.
+	WriteFiles(ctx context.Context, files []OutputFile) error
+
+	// Deletes each of `paths`, then whatever directories that leaves
+	// empty, up to but not including `root`. Paths that do not exist
+	// are not an error.
+	RemoveFiles(ctx context.Context, paths []string, root string) error
+}
+
+type Renderer struct {
+	opts Options
+}
+
+func NewRenderer(opts Options) *Renderer {
+	if opts.Prompter == nil {
+		opts.Prompter = TerminalPrompter{}
+	}
+
+	if opts.Fetcher == nil {
+		opts.Fetcher = HTTPFetcher{}
+	}
+
+	if opts.Source == nil {
+		opts.Source = DiskFS{}
+	}
+
+	if opts.Output == nil {
+		opts.Output = DiskFS{}
+	}
+
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	return &Renderer{opts}
+}
@@ -0,0 +1,200 @@
+package qveen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/veigaribo/qveen/templates"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// A protected region in a file. `Start` and `End` delimit its contents,
+// which exclude the marker lines.
+type Region struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// Wraps failures to parse the regions of a file that already exists,
+// which are not the fault of the template.
+var ErrInvalidExistingRegions = errors.New("Invalid regions in the existing file")
+
+// Finds the regions delimited by `qveen:begin <name>` and `qveen:end`
+// lines in `content`, in order.
+func ParseRegions(content []byte) ([]Region, error) {
+	var regions []Region
+	var current *Region
+	seen := make(map[string]bool)
+
+	lineNumber := 0
+
+	for offset := 0; offset < len(content); {
+		lineNumber++
+		lineEnd := bytes.IndexByte(content[offset:], '\n')
+
+		if lineEnd == -1 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += offset + 1
+		}
+
+		line := string(content[offset:lineEnd])
+
+		if i := indexMarker(line, templates.RegionBeginMarker); i != -1 {
+			fields := strings.Fields(line[i+len(templates.RegionBeginMarker):])
+
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("Region without a name at line %d", lineNumber)
+			}
+
+			name := fields[0]
+
+			if current != nil {
+				return nil, fmt.Errorf("Region '%s' begins at line %d inside region '%s'", name, lineNumber, current.Name)
+			}
+
+			if seen[name] {
+				return nil, fmt.Errorf("Region '%s' appears more than once, again at line %d", name, lineNumber)
+			}
+
+			seen[name] = true
+			current = &Region{Name: name, Start: lineEnd}
+		} else if indexMarker(line, templates.RegionEndMarker) != -1 {
+			if current == nil {
+				return nil, fmt.Errorf("Region end at line %d without a beginning", lineNumber)
+			}
+
+			current.End = offset
+			regions = append(regions, *current)
+			current = nil
+		}
+
+		offset = lineEnd
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("Region '%s' is never ended", current.Name)
+	}
+
+	return regions, nil
+}
+
+// Where `marker` is in `line` as a word of its own, or -1.
+func indexMarker(line, marker string) int {
+	isSpace := func(i int) bool {
+		return i < 0 || i >= len(line) || strings.ContainsRune(" \t\r\n", rune(line[i]))
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], marker)
+
+		if i == -1 {
+			return -1
+		}
+
+		i += offset
+		end := i + len(marker)
+
+		if isSpace(i-1) && isSpace(end) {
+			return i
+		}
+
+		offset = end
+	}
+}
+
+// Replaces the contents of each region in `rendered` with those of the
+// region with the same name in `existing`. Regions only in `existing`
+// are returned as orphaned, since their contents will be lost.
+func SpliceRegions(rendered, existing []byte) ([]byte, []string, error) {
+	newRegions, err := ParseRegions(rendered)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	oldRegions, err := ParseRegions(existing)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidExistingRegions, err)
+	}
+
+	oldContents := make(map[string][]byte, len(oldRegions))
+
+	for _, region := range oldRegions {
+		oldContents[region.Name] = existing[region.Start:region.End]
+	}
+
+	var result bytes.Buffer
+	last := 0
+
+	for _, region := range newRegions {
+		old, ok := oldContents[region.Name]
+
+		if !ok {
+			continue
+		}
+
+		result.Write(rendered[last:region.Start])
+		result.Write(old)
+		last = region.End
+
+		delete(oldContents, region.Name)
+	}
+
+	result.Write(rendered[last:])
+
+	var orphaned []string
+
+	// Keep the order in which they appear.
+	for _, region := range oldRegions {
+		if _, ok := oldContents[region.Name]; ok {
+			orphaned = append(orphaned, region.Name)
+		}
+	}
+
+	return result.Bytes(), orphaned, nil
+}
+
+// Carries the protected regions of the file at `r.OutputPath` in `fsys`
+// over to the new content, if there is such a file. The new content is
+// expected to have valid regions already. Warnings go to `stderr`.
+func preserveRegions(fsys fs.FS, r *RenderedPair, stderr io.Writer) error {
+	if !utils.IsLocal(r.OutputPath) ||
+		!bytes.Contains(r.Content, []byte(templates.RegionBeginMarker)) {
+		return nil
+	}
+
+	existing, err := fs.ReadFile(fsys, fsPath(r.OutputPath))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	content, orphaned, err := SpliceRegions(r.Content, existing)
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range orphaned {
+		fmt.Fprintf(
+			stderr,
+			"Warning: region '%s' in '%s' is not generated anymore, its contents will be lost.\n",
+			name,
+			r.OutputPath,
+		)
+	}
+
+	r.Content = content
+	return nil
+}
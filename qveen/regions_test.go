@@ -0,0 +1,135 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseRegions(t *testing.T) {
+	content := []byte("a\n// qveen:begin one\nb\n// qveen:end\n# qveen:beginning qveen:ending\n<!-- qveen:begin two -->\nc\n<!-- qveen:end -->\n")
+	regions, err := ParseRegions(content)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(regions) != 2 {
+		t.Fatalf("Expected 2 regions, got %d", len(regions))
+	}
+
+	for i, expected := range []string{"b\n", "c\n"} {
+		actual := string(content[regions[i].Start:regions[i].End])
+
+		if actual != expected {
+			t.Errorf("Expected region #%d to contain %q, got %q", i, expected, actual)
+		}
+	}
+}
+
+func TestParseRegionsErrors(t *testing.T) {
+	tests := map[string]string{
+		"unnamed":    "// qveen:begin\n// qveen:end\n",
+		"unended":    "// qveen:begin a\n",
+		"unbegun":    "// qveen:end\n",
+		"nested":     "// qveen:begin a\n// qveen:begin b\n// qveen:end\n// qveen:end\n",
+		"duplicated": "// qveen:begin a\n// qveen:end\n// qveen:begin a\n// qveen:end\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRegions([]byte(content))
+
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSpliceRegions(t *testing.T) {
+	rendered := []byte("// qveen:begin a\nnew a\n// qveen:end\n// qveen:begin b\nnew b\n// qveen:end\n")
+	existing := []byte("// qveen:begin b\nold b\n// qveen:end\n// qveen:begin gone\nlost\n// qveen:end\n")
+
+	result, orphaned, err := SpliceRegions(rendered, existing)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "// qveen:begin a\nnew a\n// qveen:end\n// qveen:begin b\nold b\n// qveen:end\n"
+
+	if string(result) != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if len(orphaned) != 1 || orphaned[0] != "gone" {
+		t.Errorf("Expected only 'gone' to be orphaned, got %v", orphaned)
+	}
+
+	_, _, err = SpliceRegions(rendered, []byte("// qveen:begin b\n"))
+
+	if !errors.Is(err, ErrInvalidExistingRegions) {
+		t.Errorf("Expected invalid existing regions, got %v", err)
+	}
+}
+
+func TestRenderKeepsRegions(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[meta]
+template = "main.tmpl"
+output = "main.go"
+`),
+		"main.tmpl": mapFile(`package main
+{{beginregion "//" "imports"}}
+{{endregion "//"}}
+func main() {}
+`),
+	}
+
+	output := MemFS{
+		"main.go": mapFile(`package main
+// qveen:begin imports
+import "fmt"
+// qveen:end
+func main() { fmt.Println() }
+`),
+	}
+
+	renderer := testRenderer(t, source, output)
+	renderer.opts.Overwrite = true
+	_, err := renderer.Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, output, "main.go", `package main
+// qveen:begin imports
+import "fmt"
+// qveen:end
+func main() {}
+`)
+}
+
+func TestRenderIgnoresMarkersWithoutRegions(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[meta]
+template = "doc.tmpl"
+output = "doc.md"
+`),
+		"doc.tmpl": mapFile("Lines with qveen:begin but no qveen:end are fine here.\n"),
+	}
+
+	output := MemFS{}
+	_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, output, "doc.md", "Lines with qveen:begin but no qveen:end are fine here.\n")
+}
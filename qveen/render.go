@@ -0,0 +1,1173 @@
+package qveen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"time"
+	"unicode"
+
+	"github.com/veigaribo/qveen/params"
+	"github.com/veigaribo/qveen/prompts"
+	"github.com/veigaribo/qveen/templates"
+	"github.com/veigaribo/qveen/utils"
+)
+
+// Generates the files described by the parameter files in
+// `paramsPaths`, merged in order. The report is filled in as far as it
+// got even if it fails.
+func (renderer *Renderer) Render(ctx context.Context, paramsPaths ...string) (Report, error) {
+	report := Report{
+		Params:  paramsPaths,
+		DryRun:  renderer.opts.DryRun,
+		Outputs: []ReportOutput{},
+	}
+
+	err := renderer.render(ctx, paramsPaths, &report)
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	return report, err
+}
+
+// Everything decided before writing anything.
+type Prepared struct {
+	Params       params.Params
+	Plan         Plan
+	IsSinglePair bool
+
+	// Set up with the delimiters and case rules of the run.
+	Engine *templates.Engine
+
+	// Empty if not keeping a manifest.
+	ManifestPath string
+}
+
+// Does everything short of writing: parsing, prompting and rendering.
+func (renderer *Renderer) prepare(
+	ctx context.Context,
+	paramsPaths []string,
+	report *Report,
+) (Prepared, error) {
+	var prep Prepared
+	var p params.Params
+	opts := renderer.opts
+
+	if len(paramsPaths) == 1 && IsBundle(paramsPaths[0]) {
+		opts.Session.AddSource(paramsPaths[0])
+		bundled, bundlePaths, err := renderer.openBundle(ctx, paramsPaths[0])
+
+		if err != nil {
+			return prep, err
+		}
+
+		// Answers still count, but files in the bundle are not on disk
+		// to be watched.
+		if opts.Session != nil {
+			bundled.opts.Session = &RenderSession{Answers: opts.Session.Answers}
+		}
+
+		prep, err = bundled.prepare(ctx, bundlePaths, report)
+
+		if opts.Session != nil {
+			opts.Session.Answers = bundled.opts.Session.Answers
+		}
+
+		return prep, err
+	}
+
+	for _, paramsPath := range paramsPaths {
+		opts.Session.AddSource(paramsPath)
+		fileParams, err := renderer.parseParamsFile(ctx, paramsPath)
+
+		if err != nil {
+			return prep, err
+		}
+
+		p.Merge(fileParams)
+	}
+
+	if len(p.Pairs) == 0 {
+		// Everything tracked in the manifest is stale then, so `Clean`
+		// still needs to know where it is.
+		manifestPath, err := renderer.manifestPath(ctx, p)
+		prep.Params = p
+		prep.ManifestPath = manifestPath
+		return prep, err
+	}
+
+	engine, err := renderer.newEngine(ctx, p)
+
+	if err != nil {
+		return prep, err
+	}
+
+	err = p.ExpandPromptParams(engine, opts.MetaKey)
+
+	if err != nil {
+		return prep, MakeRenderError(
+			templateErrorKind(err),
+			fmt.Errorf("Failed to expand prompt parameters: %w", err),
+		)
+	}
+
+	err = renderer.prefillPrompts(p)
+
+	if err != nil {
+		return prep, err
+	}
+
+	for i := range p.Prompt {
+		prompt := &p.Prompt[i]
+
+		if opts.Session != nil && prompt.Value == nil {
+			prompt.Value = opts.Session.Answers[prompt.Name]
+		}
+	}
+
+	prompted, err := opts.Prompter.Prompt(p.Prompt)
+
+	if err != nil {
+		return prep, MakeRenderError(
+			PromptErrorKind,
+			fmt.Errorf("Failed to run prompts: %w", err),
+		)
+	}
+
+	maps.Copy(p.Data, prompted)
+
+	if opts.Session != nil {
+		opts.Session.Answers = make(map[string]any)
+
+		for _, prompt := range p.Prompt {
+			opts.Session.Answers[prompt.Name] = p.Data[prompt.Name]
+		}
+	}
+
+	err = p.ExpandParams(engine, opts.MetaKey)
+
+	if err != nil {
+		return prep, MakeRenderError(
+			templateErrorKind(err),
+			fmt.Errorf("Failed to expand parameters: %w", err),
+		)
+	}
+
+	report.Data = p.Data
+
+	isSinglePair := len(p.Pairs) == 1
+
+	var templatePathFlag, outputPathFlag string
+
+	// Flags apply to the only pair, so they may need to be expanded
+	// again for each of its items.
+	expandFlags := func(data map[string]any) error {
+		var err error
+
+		if opts.TemplatePath != "" {
+			templatePathFlag, err = engine.ExpandString(
+				"--template",
+				opts.TemplatePath,
+				data,
+			)
+
+			if err != nil {
+				return MakeRenderError(
+					templateErrorKind(err),
+					fmt.Errorf("Failed to expand template path: %w", err),
+				)
+			}
+		}
+
+		if opts.OutputPath != "" {
+			outputPathFlag, err = engine.ExpandString(
+				"--output",
+				opts.OutputPath,
+				data,
+			)
+
+			if err != nil {
+				return MakeRenderError(
+					templateErrorKind(err),
+					fmt.Errorf("Failed to expand output path: %w", err),
+				)
+			}
+		}
+
+		return nil
+	}
+
+	if isSinglePair {
+		err = expandFlags(p.Data)
+
+		if err != nil {
+			return prep, err
+		}
+	} else {
+		if opts.TemplatePath != "" {
+			fmt.Fprintf(opts.Stderr, "Ignoring template flag for multiple pairs.")
+		}
+
+		if opts.OutputPath != "" {
+			if utils.IsExplicitDir(opts.OutputPath) {
+				outputPathFlag = opts.OutputPath
+			} else {
+				fmt.Fprintf(opts.Stderr, "Ignoring non-prefix output flag for multiple pairs.")
+			}
+		}
+	}
+
+	rendered := make([]RenderedPair, 0, len(p.Pairs))
+
+	instances, err := instantiatePairs(engine, p)
+
+	if err != nil {
+		return prep, err
+	}
+
+	jobs := make([]renderJob, 0, len(instances))
+
+	for _, instance := range instances {
+		i, pair, data := instance.Index, instance.Pair, instance.Data
+
+		if isSinglePair && instance.IsItem {
+			err = expandFlags(data)
+
+			if err != nil {
+				return prep, err
+			}
+		}
+
+		templatePathParams := pair.Template
+		templatePath := utils.FirstOf(
+			templatePathFlag,
+			templatePathParams.Resolve(),
+		)
+
+		if templatePath == "" {
+			if isSinglePair {
+				return prep, MakeRenderError(
+					MetaValidationErrorKind,
+					errors.New("Missing template file path."),
+				)
+			} else {
+				return prep, MakeRenderError(
+					MetaValidationErrorKind,
+					fmt.Errorf("Missing template file path for pair #%d.", i),
+				)
+			}
+		}
+
+		job := renderJob{
+			pairInstance:   instance,
+			TemplatePath:   templatePath,
+			OutputPathFlag: outputPathFlag,
+			IsSinglePair:   isSinglePair,
+			Engine:         engine,
+		}
+
+		// The pin is for the template in the parameter file.
+		if templatePathFlag == "" {
+			job.TemplateSha256 = pair.TemplateSha256
+		}
+
+		if !job.IsSkipped() {
+			opts.Session.AddSource(templatePath)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	results, err := renderer.renderAll(ctx, jobs)
+
+	if err != nil {
+		return prep, err
+	}
+
+	// In order, since pairs may inject into the outputs of earlier ones.
+	for k, job := range jobs {
+		for _, r := range results[k] {
+			if job.Pair.Inject != nil {
+				rendered, err = injectRendered(opts.Output, rendered, &r, *job.Pair.Inject)
+
+				if err != nil {
+					return prep, pairError(MetaValidationErrorKind, isSinglePair, r.Index, "inject", err)
+				}
+			}
+
+			rendered = append(rendered, r)
+		}
+	}
+
+	for i := range rendered {
+		r := &rendered[i]
+
+		if r.SkipReason != "" {
+			continue
+		}
+
+		// Only the inserted part is ours to format, and it is unlikely to
+		// be valid on its own.
+		if r.Format != "" && !r.Injected {
+			r.Content, err = FormatOutput(r.Format, r.OutputPath, r.Content)
+
+			if err != nil {
+				return prep, MakeRenderError(
+					TemplateExecutionErrorKind,
+					fmt.Errorf("Failed to format output '%s' of pair #%d: %w", r.OutputPath, r.Index, err),
+				)
+			}
+		}
+
+		// The rest of the file is not ours.
+		if r.Injected || !r.UsesRegions {
+			continue
+		}
+
+		_, err := ParseRegions(r.Content)
+
+		if err != nil {
+			return prep, MakeRenderError(
+				TemplateExecutionErrorKind,
+				fmt.Errorf("Invalid regions in output '%s' of pair #%d: %w", r.OutputPath, r.Index, err),
+			)
+		}
+
+		err = preserveRegions(opts.Output, r, opts.Stderr)
+
+		if err != nil {
+			kind := IOErrorKind
+
+			if errors.Is(err, ErrInvalidExistingRegions) {
+				kind = MetaValidationErrorKind
+			}
+
+			return prep, MakeRenderError(
+				kind,
+				fmt.Errorf("Failed to preserve regions of '%s': %w", r.OutputPath, err),
+			)
+		}
+	}
+
+	prep.Plan, err = MakePlan(opts.Output, rendered)
+
+	if err != nil {
+		return prep, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to make plan: %w", err),
+		)
+	}
+
+	prep.Params = p
+	prep.Engine = engine
+	prep.IsSinglePair = isSinglePair
+	prep.ManifestPath = utils.FirstOf(opts.ManifestPath, p.Manifest.Resolve())
+	return prep, nil
+}
+
+// Where the manifest is for parameters without pairs, which are not
+// expanded otherwise. Nothing is prompted, so only the data is
+// available to `meta.manifest`.
+func (renderer *Renderer) manifestPath(ctx context.Context, p params.Params) (string, error) {
+	opts := renderer.opts
+
+	if opts.ManifestPath != "" || p.Manifest.IsEmpty() {
+		return opts.ManifestPath, nil
+	}
+
+	engine, err := renderer.newEngine(ctx, p)
+
+	if err != nil {
+		return "", err
+	}
+
+	p.Manifest.Path, err = engine.ExpandString(
+		utils.PathString([]any{opts.MetaKey, "manifest"}),
+		p.Manifest.Path,
+		p.Data,
+	)
+
+	if err != nil {
+		return "", MakeRenderError(
+			templateErrorKind(err),
+			fmt.Errorf("Failed to expand parameters: %w", err),
+		)
+	}
+
+	return p.Manifest.Resolve(), nil
+}
+
+func (renderer *Renderer) render(
+	ctx context.Context,
+	paramsPaths []string,
+	report *Report,
+) error {
+	opts := renderer.opts
+	prep, err := renderer.prepare(ctx, paramsPaths, report)
+
+	if err != nil {
+		return err
+	}
+
+	if len(prep.Params.Pairs) == 0 {
+		fmt.Fprintf(opts.Stderr, "Nothing to do.\n")
+		return nil
+	}
+
+	plan := prep.Plan
+	isSinglePair := prep.IsSinglePair
+
+	for _, entry := range plan.Entries {
+		r := entry.Rendered
+		exists := entry.Action == PlanOverwrite || entry.Action == PlanUnchanged
+
+		if exists && !r.Injected && r.IfExists == params.IfExistsFail {
+			return pairError(
+				IOErrorKind,
+				isSinglePair,
+				r.Index,
+				"write output file",
+				fmt.Errorf("'%s' already exists", r.OutputPath),
+			)
+		}
+	}
+
+	if opts.DryRun {
+		plan.Print(opts.Stdout)
+
+		for _, entry := range plan.Entries {
+			report.AddOutput(entry.Rendered, ReportActionFor(entry.Action))
+
+			if !opts.Diff || (entry.Action != PlanOverwrite && entry.Action != PlanInject) {
+				continue
+			}
+
+			err := PrintDiff(opts.Stdout, opts.Output, entry.Rendered)
+
+			if err != nil {
+				return MakeRenderError(
+					IOErrorKind,
+					fmt.Errorf("Failed to show diff: %w", err),
+				)
+			}
+		}
+
+		return nil
+	}
+
+	var manifest *Manifest
+
+	if prep.ManifestPath != "" {
+		manifest, err = LoadManifest(opts.Output, prep.ManifestPath)
+
+		if err != nil {
+			return MakeRenderError(
+				IOErrorKind,
+				fmt.Errorf("Failed to load manifest: %w", err),
+			)
+		}
+	}
+
+	// Ask everything upfront so that nothing is written if the user
+	// refuses any of it.
+	accepted := make([]PlanEntry, 0, len(plan.Entries))
+	var skipped []PlanEntry
+
+	for _, entry := range plan.Entries {
+		r := entry.Rendered
+
+		if r.SkipReason != "" {
+			skipped = append(skipped, entry)
+			continue
+		}
+
+		if opts.Diff {
+			if entry.Action == PlanUnchanged {
+				skipped = append(skipped, entry)
+				continue
+			}
+
+			if entry.Action == PlanOverwrite || entry.Action == PlanInject {
+				err = PrintDiff(opts.Stdout, opts.Output, r)
+
+				if err != nil {
+					return MakeRenderError(
+						IOErrorKind,
+						fmt.Errorf("Failed to show diff: %w", err),
+					)
+				}
+			}
+		}
+
+		// Inserting into a file is the whole point, no need to ask.
+		if r.Injected {
+			if entry.Action == PlanUnchanged {
+				skipped = append(skipped, entry)
+			} else {
+				accepted = append(accepted, entry)
+			}
+
+			continue
+		}
+
+		showDiff := func() {
+			err := PrintDiff(opts.Stdout, opts.Output, r)
+
+			if err != nil {
+				fmt.Fprintf(opts.Stderr, "Failed to show diff: %s\n", err)
+			}
+		}
+
+		switch r.IfExists {
+		case params.IfExistsSkip:
+			if entry.Action == PlanSkip {
+				skipped = append(skipped, entry)
+				continue
+			}
+		case params.IfExistsIfChanged:
+			if entry.Action == PlanUnchanged {
+				skipped = append(skipped, entry)
+				continue
+			}
+
+			accepted = append(accepted, entry)
+			continue
+		case params.IfExistsOverwrite:
+			accepted = append(accepted, entry)
+			continue
+		}
+
+		// Files we generated ourselves may be overwritten freely, unless
+		// someone touched them since.
+		var tracked, modified bool
+
+		if manifest != nil && entry.Action == PlanOverwrite {
+			tracked, modified, err = manifest.Check(r.OutputPath)
+
+			if err != nil {
+				return pairError(IOErrorKind, isSinglePair, r.Index, "check manifest", err)
+			}
+		}
+
+		if !tracked && entry.Action == PlanOverwrite {
+			tracked, modified, err = opts.Session.check(opts.Output, r.OutputPath)
+
+			if err != nil {
+				return pairError(IOErrorKind, isSinglePair, r.Index, "check output", err)
+			}
+		}
+
+		switch {
+		case modified && !opts.Overwrite:
+			err = renderer.askOverwrite(
+				fmt.Sprintf("File '%s' was edited since it was generated. Overwrite?", r.OutputPath),
+				showDiff,
+			)
+		case modified:
+			fmt.Fprintf(opts.Stderr, "Overwriting '%s', which was edited since it was generated.\n", r.OutputPath)
+		default:
+			err = renderer.confirmOverwrite(r.OutputPath, opts.Overwrite || tracked, showDiff)
+		}
+
+		if err != nil {
+			return pairError(IOErrorKind, isSinglePair, r.Index, "write output file", err)
+		}
+
+		accepted = append(accepted, entry)
+	}
+
+	if !opts.NoHooks {
+		err = renderer.runHooks(
+			ctx,
+			prep.Engine,
+			"pre",
+			prep.Params.Hooks.Pre,
+			prep.Params.Hooks,
+			prep.Params.Data,
+			writtenFiles(accepted, -1),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if manifest != nil {
+		for _, entry := range slices.Concat(accepted, skipped) {
+			r := entry.Rendered
+
+			if utils.IsStd(r.OutputPath) || r.Injected || r.SkipReason != "" {
+				continue
+			}
+
+			// Not written, so whatever was recorded still holds.
+			if entry.Action == PlanSkip {
+				continue
+			}
+
+			err := manifest.Record(entry.Rendered, paramsPaths)
+
+			if err != nil {
+				return MakeRenderError(
+					IOErrorKind,
+					fmt.Errorf("Failed to update manifest: %w", err),
+				)
+			}
+		}
+	}
+
+	err = renderer.writeRendered(ctx, accepted, manifest)
+
+	if err != nil {
+		return MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to write output files: %w", err),
+		)
+	}
+
+	for _, entry := range accepted {
+		r := entry.Rendered
+		fmt.Fprintln(opts.Stderr, r.Index, r.TemplatePath, "->", r.OutputPath)
+
+		if !utils.IsStd(r.OutputPath) {
+			opts.Session.addWritten(r.OutputPath, r.Content)
+		}
+
+		report.AddOutput(r, ReportActionFor(entry.Action))
+	}
+
+	for _, entry := range skipped {
+		report.AddOutput(entry.Rendered, ReportActionFor(entry.Action))
+	}
+
+	if opts.NoHooks {
+		return nil
+	}
+
+	for i, pair := range prep.Params.Pairs {
+		err = renderer.runHooks(
+			ctx,
+			prep.Engine,
+			fmt.Sprintf("pair #%d post", i),
+			pair.Post,
+			prep.Params.Hooks,
+			prep.Params.Data,
+			writtenFiles(accepted, i),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return renderer.runHooks(
+		ctx,
+		prep.Engine,
+		"post",
+		prep.Params.Hooks.Post,
+		prep.Params.Hooks,
+		prep.Params.Data,
+		writtenFiles(accepted, -1),
+	)
+}
+
+// Paths of the local files in `entries` coming from pair `index`, or
+// from any pair if it is negative.
+func writtenFiles(entries []PlanEntry, index int) []string {
+	var files []string
+
+	for _, entry := range entries {
+		r := entry.Rendered
+
+		if utils.IsStd(r.OutputPath) || (index >= 0 && r.Index != index) {
+			continue
+		}
+
+		files = append(files, r.OutputPath)
+	}
+
+	return files
+}
+
+// Only mentions the pair if there are multiple of them.
+func pairError(
+	kind ErrorKind,
+	isSinglePair bool,
+	i int,
+	action string,
+	err error,
+) error {
+	if isSinglePair {
+		return MakeRenderError(
+			kind,
+			fmt.Errorf("Failed to %s: %w", action, err),
+		)
+	}
+
+	return MakeRenderError(
+		kind,
+		fmt.Errorf("Failed to %s for pair #%d: %w", action, i, err),
+	)
+}
+
+// What was learned during a render, for use in later ones.
+type RenderSession struct {
+	// Local files and directories that were read.
+	Sources []string
+
+	// Values given to each prompt, by name.
+	Answers map[string]any
+
+	// Hashes of what was written to each output, by path, so that later
+	// renders know which files are theirs.
+	Written map[string]string
+}
+
+func (s *RenderSession) addWritten(path string, content []byte) {
+	if s == nil {
+		return
+	}
+
+	if s.Written == nil {
+		s.Written = make(map[string]string)
+	}
+
+	s.Written[path] = hashContent(content)
+}
+
+// Like `Manifest.Check`, but for the files written during the session.
+func (s *RenderSession) check(fsys fs.FS, path string) (tracked, modified bool, err error) {
+	if s == nil {
+		return false, false, nil
+	}
+
+	sum, tracked := s.Written[path]
+
+	if !tracked {
+		return false, false, nil
+	}
+
+	content, err := fs.ReadFile(fsys, fsPath(path))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, false, nil
+	}
+
+	if err != nil {
+		return true, false, err
+	}
+
+	return true, hashContent(content) != sum, nil
+}
+
+func (s *RenderSession) AddSource(path string) {
+	if s == nil || !utils.IsLocal(path) {
+		return
+	}
+
+	s.Sources = append(s.Sources, path)
+}
+
+// Result of executing the template of a pair, kept in memory until we
+// are sure every pair can be rendered.
+type RenderedPair struct {
+	Index        int
+	TemplatePath string
+	OutputPath   string
+	Content      []byte
+
+	// Whether `Content` is an existing file with the result of the
+	// template inserted into it.
+	Injected bool
+
+	// Policy of the pair for existing files. See `params.IfExistsPolicies`.
+	IfExists string
+
+	// Exact permissions to give the file. 0 means the default.
+	Mode fs.FileMode
+
+	// If set, `Mode` does not apply to files that already exist.
+	PreserveMode bool
+
+	// How to reformat `Content` before writing. See `params.OutputFormats`.
+	Format string
+
+	// Whether the template produced region markers, in which case they
+	// are checked and the regions of the existing file are kept.
+	UsesRegions bool
+
+	// If set, the pair was not rendered at all, for this reason, and
+	// there is no content.
+	SkipReason string
+
+	// How long it took to produce `Content`.
+	Duration time.Duration
+}
+
+// Turns `r` into the file at its output path in `fsys` with its content
+// inserted. If an earlier entry in `rendered` has the same output, it
+// is taken out and its content is used instead of the file, so that
+// pairs may build on one another.
+func injectRendered(
+	fsys fs.FS,
+	rendered []RenderedPair,
+	r *RenderedPair,
+	inject params.ParamsInject,
+) ([]RenderedPair, error) {
+	if !utils.IsLocal(r.OutputPath) {
+		return rendered, fmt.Errorf("Cannot inject into '%s', which is not a local file", r.OutputPath)
+	}
+
+	injection, err := CompileInjection(inject)
+
+	if err != nil {
+		return rendered, err
+	}
+
+	var existing []byte
+	found := false
+
+	// A file produced by us is still ours after injecting into it.
+	injected := true
+
+	for i := len(rendered) - 1; i >= 0; i-- {
+		if rendered[i].OutputPath == r.OutputPath {
+			existing = rendered[i].Content
+			injected = rendered[i].Injected
+			rendered = slices.Delete(rendered, i, i+1)
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		existing, err = fs.ReadFile(fsys, fsPath(r.OutputPath))
+
+		if err != nil {
+			return rendered, fmt.Errorf("Cannot inject into '%s': %w", r.OutputPath, err)
+		}
+	}
+
+	r.Content, err = injection.Apply(existing, r.Content)
+
+	if err != nil {
+		return rendered, fmt.Errorf("Failed to inject into '%s': %w", r.OutputPath, err)
+	}
+
+	r.Injected = injected
+	return rendered, nil
+}
+
+// Writes every file or none of them. The manifest, if not nil, is
+// written along with them.
+func (renderer *Renderer) writeRendered(
+	ctx context.Context,
+	entries []PlanEntry,
+	manifest *Manifest,
+) error {
+	files := make([]OutputFile, 0, len(entries)+1)
+	var toStdout []RenderedPair
+
+	for _, entry := range entries {
+		r := entry.Rendered
+
+		if utils.IsStd(r.OutputPath) {
+			toStdout = append(toStdout, r)
+			continue
+		}
+
+		mode := r.Mode
+
+		if r.PreserveMode && entry.Action != PlanCreate {
+			mode = 0
+		}
+
+		files = append(files, OutputFile{
+			Path:    r.OutputPath,
+			Content: r.Content,
+			Mode:    mode,
+		})
+	}
+
+	if manifest != nil {
+		content, err := manifest.Content()
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, OutputFile{
+			Path:    manifest.Path(),
+			Content: content,
+		})
+	}
+
+	err := renderer.opts.Output.WriteFiles(ctx, files)
+
+	if err != nil {
+		return err
+	}
+
+	// Can't take that back, so leave it for last.
+	for _, r := range toStdout {
+		_, err := renderer.opts.Stdout.Write(r.Content)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Asks the user whether it is OK to write over `path`, if it already
+// exists. `showDiff`, if not nil, allows them to ask to see what would
+// change first.
+func (renderer *Renderer) confirmOverwrite(
+	path string,
+	skipConfirm bool,
+	showDiff func(),
+) error {
+	if path == "" {
+		return errors.New("Tried to write to an empty file path")
+	}
+
+	if utils.IsStd(path) {
+		return nil
+	}
+
+	if !utils.IsLocal(path) {
+		return fmt.Errorf("Tried to write to '%s', which is not a local file", path)
+	}
+
+	stat, err := fs.Stat(renderer.opts.Output, fsPath(path))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if stat.IsDir() {
+		return fmt.Errorf("Destination '%s' already exists and is a directory", path)
+	}
+
+	if skipConfirm {
+		return nil
+	}
+
+	return renderer.askOverwrite(
+		fmt.Sprintf("File '%s' already exists. Overwrite?", path),
+		showDiff,
+	)
+}
+
+// Returns `utils.ErrAborted` if the user says no. `showDiff` is as in
+// `confirmOverwrite`.
+func (renderer *Renderer) askOverwrite(title string, showDiff func()) error {
+	prompter := renderer.opts.Prompter
+
+	if showDiff == nil {
+		ok, err := prompter.Confirm(title)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		return utils.ErrAborted
+	}
+
+	for {
+		choice, err := prompter.ConfirmOverwrite(title)
+
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case prompts.OverwriteYes:
+			return nil
+		case prompts.OverwriteShowDiff:
+			showDiff()
+		default:
+			return utils.ErrAborted
+		}
+	}
+}
+
+// Sets up the delimiters and case rules of `p`, or those of the
+// options, and loads its partials.
+func (renderer *Renderer) newEngine(
+	ctx context.Context,
+	p params.Params,
+) (*templates.Engine, error) {
+	opts := renderer.opts
+
+	var kase unicode.SpecialCase
+	templateCase := utils.FirstOf(opts.TemplateCase, p.TemplateCase)
+
+	switch templateCase {
+	case "turkish":
+		kase = unicode.TurkishCase
+	case "azeri":
+		kase = unicode.AzeriCase
+	case "":
+		kase = templates.NotSpecialCase
+	default:
+		return nil, MakeRenderError(
+			MetaValidationErrorKind,
+			fmt.Errorf("Invalid value for `case`: '%s'. Expected 'turkish' or 'azeri' (or empty).", templateCase),
+		)
+	}
+
+	engine, err := templates.NewEngine(templates.EngineOptions{
+		LeftDelim:  utils.FirstOf(opts.TemplateLeftDelim, p.TemplateLeftDelim),
+		RightDelim: utils.FirstOf(opts.TemplateRightDelim, p.TemplateRightDelim),
+		Case:       kase,
+		Stderr:     opts.Stderr,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	partialPaths := make([]string, 0, len(p.Partials)+len(opts.Partials))
+
+	for _, partial := range p.Partials {
+		partialPaths = append(partialPaths, partial.Resolve())
+	}
+
+	err = renderer.loadPartials(ctx, engine, append(partialPaths, opts.Partials...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+// Gives prompts the values in `Options.PromptValues`.
+func (renderer *Renderer) prefillPrompts(p params.Params) error {
+	for i := range p.Prompt {
+		prompt := &p.Prompt[i]
+		prefill, ok := renderer.opts.PromptValues[prompt.Name]
+
+		if !ok {
+			continue
+		}
+
+		err := prompt.TryPrefill(prefill)
+
+		if err != nil {
+			return MakeRenderError(
+				PromptErrorKind,
+				fmt.Errorf("Failed to prefill prompt '%s' with '%s': %w", prompt.Name, prefill, err),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (renderer *Renderer) parseParamsFile(
+	ctx context.Context,
+	paramsPath string,
+) (params.Params, error) {
+	var p params.Params
+	opts := renderer.opts
+	paramsReader, err := renderer.open(ctx, paramsPath)
+
+	if err != nil {
+		return p, MakeRenderError(
+			IOErrorKind,
+			fmt.Errorf("Failed to open parameter file '%s': %w", paramsPath, err),
+		)
+	}
+
+	defer paramsReader.Close()
+
+	var paramsFormat params.ParamsFormat
+
+	switch opts.ParamsFormat {
+	case "toml":
+		paramsFormat = params.ParamsTomlFormat
+	case "yaml", "json":
+		paramsFormat = params.ParamsYamlFormat
+	case "":
+		maybeParamsFormat := params.GuessFormat(paramsPath)
+
+		if maybeParamsFormat == nil {
+			return p, MakeRenderError(
+				ParamsParseErrorKind,
+				fmt.Errorf("Could not guess params format from file name: %s", paramsPath),
+			)
+		}
+
+		paramsFormat = *maybeParamsFormat
+	default:
+		return p, MakeRenderError(
+			ParamsParseErrorKind,
+			fmt.Errorf("Unrecognized format '%s'", opts.ParamsFormat),
+		)
+	}
+
+	p, err = params.ParseParams(
+		paramsReader,
+		paramsFormat,
+
+		params.ParseParamsOptions{
+			MetaKey: opts.MetaKey,
+			Source:  paramsPath,
+		},
+	)
+
+	if err != nil {
+		return p, MakeRenderError(
+			paramsErrorKind(err),
+			fmt.Errorf("Failed to parse parameter file '%s': %w", paramsPath, err),
+		)
+	}
+
+	return p, nil
+}
+
+// Opens a file from `Options.Source`, a URL, or stdin if `path` is `-`.
+func (renderer *Renderer) open(ctx context.Context, path string) (io.ReadCloser, error) {
+	if path == "" {
+		return nil, errors.New("Tried to open an empty file path")
+	}
+
+	if utils.IsStd(path) {
+		return io.NopCloser(renderer.opts.Stdin), nil
+	}
+
+	if utils.IsUrl(path) {
+		return renderer.opts.Fetcher.Fetch(ctx, path)
+	}
+
+	return renderer.opts.Source.Open(fsPath(path))
+}
+
+// Like `open`, but lets a `PinnedFetcher` know what the contents must
+// hash to, if `sum` is set. They are not checked here.
+func (renderer *Renderer) openPinned(ctx context.Context, path string, sum string) (io.ReadCloser, error) {
+	fetcher, ok := renderer.opts.Fetcher.(PinnedFetcher)
+
+	if sum != "" && ok && utils.IsUrl(path) {
+		return fetcher.FetchPinned(ctx, path, sum)
+	}
+
+	return renderer.open(ctx, path)
+}
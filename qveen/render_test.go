@@ -0,0 +1,108 @@
+package qveen
+
+import (
+	"context"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/veigaribo/qveen/prompts"
+)
+
+// Fails the test if anything is asked.
+type noPrompter struct {
+	t *testing.T
+}
+
+func (p noPrompter) Prompt(ps []prompts.Prompt) (map[string]any, error) {
+	values := make(map[string]any)
+
+	for _, prompt := range ps {
+		if prompt.Value == nil {
+			p.t.Fatalf("Unexpected prompt '%s'", prompt.Name)
+		}
+
+		values[prompt.Name] = prompt.Value
+	}
+
+	return values, nil
+}
+
+func (p noPrompter) Confirm(title string) (bool, error) {
+	p.t.Fatalf("Unexpected confirmation: %s", title)
+	return false, nil
+}
+
+func (p noPrompter) ConfirmOverwrite(title string) (prompts.OverwriteChoice, error) {
+	p.t.Fatalf("Unexpected confirmation: %s", title)
+	return prompts.OverwriteNo, nil
+}
+
+func testRenderer(t *testing.T, source fstest.MapFS, output MemFS) *Renderer {
+	return NewRenderer(Options{
+		Prompter: noPrompter{t},
+		Source:   source,
+		Output:   output,
+		Stdout:   io.Discard,
+		Stderr:   io.Discard,
+	})
+}
+
+func mapFile(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content), Mode: 0644}
+}
+
+func assertFile(t *testing.T, output MemFS, name, expected string) {
+	t.Helper()
+	file, ok := output[name]
+
+	if !ok {
+		t.Fatalf("Expected '%s' to be written", name)
+	}
+
+	if string(file.Data) != expected {
+		t.Errorf("Expected '%s' to contain %q, got %q", name, expected, file.Data)
+	}
+}
+
+func TestRenderMapFSIntoMemFS(t *testing.T) {
+	source := fstest.MapFS{
+		"generators/params.toml": mapFile(`
+name = "users"
+
+[meta]
+template = { path = "handler.tmpl", from = "params" }
+output = "handlers/{{.name}}.go"
+`),
+		"generators/handler.tmpl": mapFile("package handlers // {{.name}}\n"),
+	}
+
+	output := MemFS{}
+	_, err := testRenderer(t, source, output).Render(context.Background(), "generators/params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, output, "handlers/users.go", "package handlers // users\n")
+
+	if len(output) != 1 {
+		t.Errorf("Expected only one file to be written, got %d", len(output))
+	}
+}
+
+func TestMemFSWritesAllOrNothing(t *testing.T) {
+	output := MemFS{}
+	err := output.WriteFiles(context.Background(), []OutputFile{
+		{Path: "a.txt", Content: []byte("a")},
+		{Path: "../b.txt", Content: []byte("b")},
+	})
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if len(output) != 0 {
+		t.Errorf("Expected nothing to be written, got %d files", len(output))
+	}
+}
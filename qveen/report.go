@@ -0,0 +1,91 @@
+package qveen
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type ReportAction string
+
+const (
+	ReportCreated     ReportAction = "created"
+	ReportOverwritten ReportAction = "overwritten"
+	ReportInjected    ReportAction = "injected"
+	ReportUnchanged   ReportAction = "unchanged"
+	ReportSkipped     ReportAction = "skipped"
+	ReportPrinted     ReportAction = "printed"
+)
+
+func ReportActionFor(action PlanAction) ReportAction {
+	switch action {
+	case PlanCreate:
+		return ReportCreated
+	case PlanOverwrite:
+		return ReportOverwritten
+	case PlanInject:
+		return ReportInjected
+	case PlanUnchanged:
+		return ReportUnchanged
+	case PlanStdout:
+		return ReportPrinted
+	}
+
+	return ReportSkipped
+}
+
+type ReportOutput struct {
+	Pair     int          `json:"pair"`
+	Template string       `json:"template"`
+	Output   string       `json:"output"`
+	Action   ReportAction `json:"action"`
+	Sha256   string       `json:"sha256,omitempty"`
+
+	// Why the pair was not rendered, if it was not.
+	Reason string `json:"reason,omitempty"`
+
+	// Time taken to load, parse and execute the template.
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Machine-readable description of a run.
+type Report struct {
+	Params []string       `json:"params"`
+	Data   map[string]any `json:"data"`
+
+	// If set, actions describe what would have happened.
+	DryRun bool `json:"dry_run"`
+
+	Outputs []ReportOutput `json:"outputs"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func (r *Report) AddOutput(rendered RenderedPair, action ReportAction) {
+	output := ReportOutput{
+		Pair:       rendered.Index,
+		Template:   rendered.TemplatePath,
+		Output:     rendered.OutputPath,
+		Action:     action,
+		Reason:     rendered.SkipReason,
+		DurationMs: float64(rendered.Duration) / float64(time.Millisecond),
+	}
+
+	if rendered.SkipReason == "" {
+		output.Sha256 = hashContent(rendered.Content)
+	}
+
+	r.Outputs = append(r.Outputs, output)
+}
+
+// As indented JSON.
+func (r *Report) Write(w io.Writer) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	content = append(content, '\n')
+	_, err = w.Write(content)
+	return err
+}
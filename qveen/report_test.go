@@ -0,0 +1,117 @@
+package qveen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReportActions(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+name = "report"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "created.txt"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "overwritten.txt"
+if_exists = "overwrite"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "unchanged.txt"
+if_exists = "if_changed"
+
+[[meta.pairs]]
+template = "template.tmpl"
+output = "skipped.txt"
+when = "false"
+`),
+		"template.tmpl": mapFile("{{.name}}\n"),
+	}
+
+	output := MemFS{
+		"overwritten.txt": mapFile("old\n"),
+		"unchanged.txt":   mapFile("report\n"),
+	}
+
+	report, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]ReportAction{
+		"created.txt":     ReportCreated,
+		"overwritten.txt": ReportOverwritten,
+		"unchanged.txt":   ReportUnchanged,
+		"skipped.txt":     ReportSkipped,
+	}
+
+	if len(report.Outputs) != len(expected) {
+		t.Fatalf("Expected %d outputs, got %d", len(expected), len(report.Outputs))
+	}
+
+	for i, reported := range report.Outputs {
+		if reported.Pair != i {
+			t.Errorf("Expected outputs in the order of the pairs, got pair #%d at %d", reported.Pair, i)
+		}
+
+		if reported.Action != expected[reported.Output] {
+			t.Errorf("Expected '%s' to be %s, got %s", reported.Output, expected[reported.Output], reported.Action)
+		}
+
+		skipped := reported.Action == ReportSkipped
+
+		if skipped != (reported.Reason != "") || skipped != (reported.Sha256 == "") {
+			t.Errorf("Expected only skipped outputs to have a reason and no hash, got %+v", reported)
+		}
+	}
+
+	if report.Data["name"] != "report" {
+		t.Errorf("Expected the data in the report, got %v", report.Data)
+	}
+
+	var buffer bytes.Buffer
+	err = report.Write(&buffer)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Report
+	err = json.Unmarshal(buffer.Bytes(), &decoded)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.Outputs) != len(expected) || decoded.Params[0] != "params.toml" {
+		t.Errorf("Expected the written report to round trip, got %+v", decoded)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[meta]
+template = "missing.tmpl"
+output = "result.txt"
+`),
+	}
+
+	report, err := testRenderer(t, source, MemFS{}).Render(context.Background(), "params.toml")
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if report.Error != err.Error() {
+		t.Errorf("Expected the report to have the error %q, got %q", err, report.Error)
+	}
+}
@@ -0,0 +1,75 @@
+package qveen
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/veigaribo/qveen/prompts"
+)
+
+// Says no to overwriting, and counts how many times it was asked.
+type refusingPrompter struct {
+	noPrompter
+	asked *int
+}
+
+func (p refusingPrompter) ConfirmOverwrite(title string) (prompts.OverwriteChoice, error) {
+	*p.asked++
+	return prompts.OverwriteNo, nil
+}
+
+func TestSessionRendersAgain(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+[meta]
+template = "template.tmpl"
+output = "result.txt"
+`),
+		"template.tmpl": mapFile("first\n"),
+	}
+
+	output := MemFS{}
+	session := &RenderSession{}
+	asked := 0
+
+	renderer := testRenderer(t, source, output)
+	renderer.opts.Session = session
+	renderer.opts.Prompter = refusingPrompter{noPrompter{t}, &asked}
+
+	_, err := renderer.Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(session.Sources, "params.toml") || !slices.Contains(session.Sources, "template.tmpl") {
+		t.Errorf("Expected the parameter file and template to be watched, got %v", session.Sources)
+	}
+
+	// Written during the session, so it is overwritten without asking.
+	source["template.tmpl"] = mapFile("second\n")
+	_, err = renderer.Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, output, "result.txt", "second\n")
+
+	if asked != 0 {
+		t.Errorf("Expected not to be asked, was asked %d times", asked)
+	}
+
+	// Edited by hand since, so it is not.
+	output["result.txt"] = mapFile("edited\n")
+	source["template.tmpl"] = mapFile("third\n")
+	renderer.Render(context.Background(), "params.toml")
+
+	assertFile(t, output, "result.txt", "edited\n")
+
+	if asked != 1 {
+		t.Errorf("Expected to be asked once, was asked %d times", asked)
+	}
+}
@@ -0,0 +1,60 @@
+package qveen
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/veigaribo/qveen/params"
+)
+
+func TestIsFalsy(t *testing.T) {
+	tests := map[string]bool{
+		"":           true,
+		" false\n":   true,
+		"0":          true,
+		"<no value>": true,
+		"true":       false,
+		"no":         false,
+		"1":          false,
+	}
+
+	for value, expected := range tests {
+		if params.IsFalsy(value) != expected {
+			t.Errorf("Expected IsFalsy(%q) to be %v", value, expected)
+		}
+	}
+}
+
+func TestRenderWhen(t *testing.T) {
+	source := fstest.MapFS{
+		"params.toml": mapFile(`
+enabled = false
+
+[[meta.pairs]]
+template = "a.tmpl"
+output = "a.txt"
+when = "{{.enabled}}"
+
+[[meta.pairs]]
+template = "b.tmpl"
+output = "b.txt"
+when = "{{not .enabled}}"
+`),
+		"a.tmpl": mapFile("a\n"),
+		"b.tmpl": mapFile("b\n"),
+	}
+
+	output := MemFS{}
+	_, err := testRenderer(t, source, output).Render(context.Background(), "params.toml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := output["a.txt"]; ok {
+		t.Error("Expected 'a.txt' to be skipped")
+	}
+
+	assertFile(t, output, "b.txt", "b\n")
+}
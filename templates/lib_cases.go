@@ -7,18 +7,22 @@ import (
 )
 
 var NotSpecialCase unicode.SpecialCase = unicode.SpecialCase{}
-var Case unicode.SpecialCase = NotSpecialCase
 
-func TemplateUpperCase(str string) string {
-	return strings.ToUpperSpecial(Case, str)
+// Case functions following some language's rules.
+type Casing struct {
+	Case unicode.SpecialCase
 }
 
-func TemplateLowerCase(str string) string {
-	return strings.ToLowerSpecial(Case, str)
+func (c Casing) UpperCase(str string) string {
+	return strings.ToUpperSpecial(c.Case, str)
+}
+
+func (c Casing) LowerCase(str string) string {
+	return strings.ToLowerSpecial(c.Case, str)
 }
 
 // `str` should be separated by spaces.
-func TemplateTitleCase(str string) string {
+func (c Casing) TitleCase(str string) string {
 	var builder strings.Builder
 	builder.Grow(len(str))
 
@@ -28,7 +32,7 @@ func TemplateTitleCase(str string) string {
 		if unicode.IsSpace(r) {
 			shouldUp = true
 		} else if shouldUp {
-			r = Case.ToTitle(r)
+			r = c.Case.ToTitle(r)
 			shouldUp = false
 		}
 
@@ -39,7 +43,7 @@ func TemplateTitleCase(str string) string {
 }
 
 // `str` should be separated by spaces.
-func TemplatePascalCase(str string) string {
+func (c Casing) PascalCase(str string) string {
 	var builder strings.Builder
 	builder.Grow(len(str))
 
@@ -52,7 +56,7 @@ func TemplatePascalCase(str string) string {
 		}
 
 		if shouldUp {
-			r = Case.ToTitle(r)
+			r = c.Case.ToTitle(r)
 			shouldUp = false
 		}
 
@@ -63,7 +67,7 @@ func TemplatePascalCase(str string) string {
 }
 
 // `str` should be separated by spaces.
-func TemplateCamelCase(str string) string {
+func (c Casing) CamelCase(str string) string {
 	var builder strings.Builder
 	builder.Grow(len(str))
 
@@ -76,7 +80,7 @@ func TemplateCamelCase(str string) string {
 		}
 
 		if shouldUp {
-			r = Case.ToTitle(r)
+			r = c.Case.ToTitle(r)
 			shouldUp = false
 		}
 
@@ -119,7 +123,7 @@ func TemplateKebabCase(str string) string {
 }
 
 // `str` should be separated by spaces.
-func TemplateConstantCase(str string) string {
+func (c Casing) ConstantCase(str string) string {
 	var builder strings.Builder
 	builder.Grow(len(str))
 
@@ -127,7 +131,7 @@ func TemplateConstantCase(str string) string {
 		if unicode.IsSpace(r) {
 			builder.WriteRune('_')
 		} else {
-			builder.WriteRune(Case.ToUpper(r))
+			builder.WriteRune(c.Case.ToUpper(r))
 		}
 	}
 
@@ -151,12 +155,12 @@ func TemplateDotCase(str string) string {
 }
 
 // `str` should be separated by spaces.
-func TemplateSentenceCase(str string) string {
+func (c Casing) SentenceCase(str string) string {
 	var builder strings.Builder
 	builder.Grow(len(str))
 
 	head, offset := utf8.DecodeRuneInString(str)
-	builder.WriteRune(Case.ToTitle(head))
+	builder.WriteRune(c.Case.ToTitle(head))
 	builder.WriteString(str[offset:])
 
 	return builder.String()
@@ -46,3 +46,8 @@ func EscapeHtml(str string) string {
 func Replace(from, to, str string) string {
 	return strings.ReplaceAll(str, from, to)
 }
+
+// Quotes `str` for POSIX shells, so that it is read as a single word.
+func ShellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
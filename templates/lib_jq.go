@@ -29,6 +29,17 @@ func TemplateJq1(query string, obj any) (any, error) {
 
 // Runs jq query and returns all results.
 func TemplateJqN(query string, obj any) ([]any, error) {
+	results, err := RunJq(query, resolvePointers(obj))
+
+	for i, result := range results {
+		results[i] = PrepareData(result)
+	}
+
+	return results, err
+}
+
+// Like `TemplateJqN`, but for data that is not in template form.
+func RunJq(query string, obj any) ([]any, error) {
 	q, err := gojq.Parse(query)
 	results := make([]any, 0)
 
@@ -36,7 +47,7 @@ func TemplateJqN(query string, obj any) ([]any, error) {
 		return results, err
 	}
 
-	iter := q.Run(resolvePointers(obj))
+	iter := q.Run(obj)
 
 	for {
 		v, ok := iter.Next()
@@ -51,7 +62,7 @@ func TemplateJqN(query string, obj any) ([]any, error) {
 			return results, err
 		}
 
-		results = append(results, PrepareData(v))
+		results = append(results, v)
 	}
 
 	return results, nil
@@ -0,0 +1,45 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/veigaribo/template"
+)
+
+// Markers delimiting regions whose contents are kept when a file is
+// generated again. They may be anywhere in their lines, so that they can
+// be inside comments of any language, but must be words of their own.
+const (
+	RegionBeginMarker = "qveen:begin"
+	RegionEndMarker   = "qveen:end"
+)
+
+// Returns the line that begins the region `name`, after `comment`.
+func TemplateBeginRegion(comment string, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("Region name must not be empty")
+	}
+
+	return fmt.Sprintf("%s %s %s", comment, RegionBeginMarker, name), nil
+}
+
+// Returns the line that ends the current region, after `comment`.
+func TemplateEndRegion(comment string) string {
+	return fmt.Sprintf("%s %s", comment, RegionEndMarker)
+}
+
+// Makes `t` set `*used` when it produces a region marker, so that only
+// the outputs of templates using regions are checked for them. To be
+// called before parsing.
+func TrackRegions(t *template.Template, used *bool) *template.Template {
+	return t.Funcs(template.FuncMap{
+		"beginregion": func(comment string, name string) (string, error) {
+			*used = true
+			return TemplateBeginRegion(comment, name)
+		},
+		"endregion": func(comment string) string {
+			*used = true
+			return TemplateEndRegion(comment)
+		},
+	})
+}
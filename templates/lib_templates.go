@@ -3,7 +3,7 @@ package templates
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -12,7 +12,12 @@ func TemplateErr(reason string) (string, error) {
 	return "", errors.New(reason)
 }
 
-func TemplateDump(objs ...any) string {
+// Functions that print values for debugging.
+type Debug struct {
+	Out io.Writer
+}
+
+func (d Debug) Dump(objs ...any) string {
 	var head any
 
 	if len(objs) == 0 {
@@ -20,20 +25,20 @@ func TemplateDump(objs ...any) string {
 	}
 
 	head = objs[0]
-	fmt.Fprint(os.Stderr, dump(head))
+	fmt.Fprint(d.Out, dump(head))
 
 	for _, obj := range objs[1:] {
-		fmt.Fprint(os.Stderr, " ")
-		fmt.Fprint(os.Stderr, dump(obj))
+		fmt.Fprint(d.Out, " ")
+		fmt.Fprint(d.Out, dump(obj))
 	}
 
 wrapup:
-	fmt.Fprint(os.Stderr, "\n")
+	fmt.Fprint(d.Out, "\n")
 	return ""
 }
 
-func TemplateProbe(obj any) any {
-	fmt.Fprintln(os.Stderr, dump(obj))
+func (d Debug) Probe(obj any) any {
+	fmt.Fprintln(d.Out, dump(obj))
 	return obj
 }
 
@@ -2,10 +2,20 @@ package templates
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
 	"github.com/veigaribo/template"
 )
 
+// Functions available to every template. Those depending on the
+// engine, such as the case functions, are added by `NewEngine`.
 var Funcs = template.FuncMap{
 	"add": TemplateAdd,
 	"sub": TemplateSub,
@@ -15,20 +25,14 @@ var Funcs = template.FuncMap{
 
 	"join": TemplateJoinFn,
 
-	"uppercase":    TemplateUpperCase,
-	"lowercase":    TemplateLowerCase,
-	"titlecase":    TemplateTitleCase,
-	"pascalcase":   TemplatePascalCase,
-	"camelcase":    TemplateCamelCase,
-	"snakecase":    TemplateSnakeCase,
-	"kebabcase":    TemplateKebabCase,
-	"constcase":    TemplateConstantCase,
-	"dotcase":      TemplateDotCase,
-	"sentencecase": TemplateSentenceCase,
+	"snakecase": TemplateSnakeCase,
+	"kebabcase": TemplateKebabCase,
+	"dotcase":   TemplateDotCase,
 
 	"escapebackslash": EscapeBackslash,
 	"escapedouble":    EscapeDouble,
 	"escapehtml":      EscapeHtml,
+	"shellquote":      ShellQuote,
 	"repl":            Replace,
 
 	"ismap": TemplateIsMap,
@@ -45,43 +49,171 @@ var Funcs = template.FuncMap{
 	"jq1": TemplateJq1,
 	"jqn": TemplateJqN,
 
-	"err":   TemplateErr,
-	"dump":  TemplateDump,
-	"probe": TemplateProbe,
+	"err": TemplateErr,
 
 	"toml": TemplateToToml,
 	"yaml": TemplateToYaml,
 	"json": TemplateToJson,
+
+	"beginregion": TemplateBeginRegion,
+	"endregion":   TemplateEndRegion,
 }
 
-var LeftDelim string = ""
-var RightDelim string = ""
+type EngineOptions struct {
+	// Empty means the default of `{{` and `}}`.
+	LeftDelim  string
+	RightDelim string
 
-var baseTemplate *template.Template
+	// Rules for the case functions. The zero value means the usual
+	// ones.
+	Case unicode.SpecialCase
+
+	// Where `dump` and `probe` print to. Stderr if nil.
+	Stderr io.Writer
+}
 
-func Init() {
+// Parses and expands templates with a fixed set of delimiters and case
+// rules. Independent from other engines and safe for concurrent use,
+// once done adding partials.
+type Engine struct {
+	base *template.Template
+
+	leftDelim  string
+	rightDelim string
+	funcs      template.FuncMap
+
+	// Where each of the templates available to all others was defined,
+	// by name.
+	sources map[string]string
+}
+
+// Source of the templates defined by Qveen itself.
+const builtinSource = "builtin"
+
+func NewEngine(opts EngineOptions) (*Engine, error) {
 	var err error
 
-	baseTemplate = template.
+	stderr := opts.Stderr
+
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	casing := Casing{opts.Case}
+	debug := Debug{stderr}
+
+	funcs := maps.Clone(Funcs)
+	maps.Copy(funcs, template.FuncMap{
+		"uppercase":    casing.UpperCase,
+		"lowercase":    casing.LowerCase,
+		"titlecase":    casing.TitleCase,
+		"pascalcase":   casing.PascalCase,
+		"camelcase":    casing.CamelCase,
+		"constcase":    casing.ConstantCase,
+		"sentencecase": casing.SentenceCase,
+
+		"dump":  debug.Dump,
+		"probe": debug.Probe,
+	})
+
+	base := template.
 		New("qveen").
-		Delims(LeftDelim, RightDelim).
-		Funcs(Funcs)
+		Delims(opts.LeftDelim, opts.RightDelim).
+		Funcs(funcs)
 
 	builtinTemplates := []string{
 		TemplateJoinT,
 	}
 
 	for i, builtin := range builtinTemplates {
-		baseTemplate, err = baseTemplate.Parse(builtin)
+		base, err = base.Parse(builtin)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse builtin template #%d! %w", i, err)
+		}
+	}
+
+	engine := Engine{
+		base:       base,
+		leftDelim:  opts.LeftDelim,
+		rightDelim: opts.RightDelim,
+		funcs:      funcs,
+		sources:    make(map[string]string),
+	}
+
+	for _, t := range base.Templates() {
+		if t.Name() != base.Name() {
+			engine.sources[t.Name()] = builtinSource
+		}
+	}
+
+	return &engine, nil
+}
+
+// Makes the templates defined in `content` available to every template
+// parsed from now on. Anything outside of definitions is ignored.
+// `source` is where they come from, to tell where a template with the
+// same name was already defined. Not to be called while the engine is
+// in use.
+func (e *Engine) AddPartials(source, content string) error {
+	partials, err := template.
+		New(source).
+		Delims(e.leftDelim, e.rightDelim).
+		Funcs(e.funcs).
+		Parse(content)
+
+	if err != nil {
+		return err
+	}
+
+	defined := partials.Templates()
+
+	slices.SortFunc(defined, func(a, b *template.Template) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	for _, t := range defined {
+		name := t.Name()
+
+		if name == source {
+			continue
+		}
+
+		other, ok := e.sources[name]
+
+		if ok {
+			return fmt.Errorf("Template '%s' is defined both in '%s' and in '%s'", name, other, source)
+		}
+
+		_, err := e.base.AddParseTree(name, t.Tree)
 
 		if err != nil {
-			panic(fmt.Errorf("Failed to parse builtin template #%d! %w", i, err))
+			return err
 		}
+
+		e.sources[name] = source
+	}
+
+	return nil
+}
+
+// Whether `content` has anything to expand.
+func (e *Engine) IsTemplated(content string) bool {
+	leftDelim := e.leftDelim
+
+	if leftDelim == "" {
+		leftDelim = "{{"
 	}
+
+	return strings.Contains(content, leftDelim)
 }
 
-func GetTemplate() *template.Template {
-	return template.Must(baseTemplate.Clone())
+// A fresh template with every function and builtin template, ready to
+// parse into.
+func (e *Engine) GetTemplate() *template.Template {
+	// Only fails for templates that were executed, which `base` never
+	// is.
+	return template.Must(e.base.Clone())
 }
 
 // Converts containers to pointers to containers.
@@ -108,13 +240,12 @@ func PrepareData(data any) any {
 	}
 }
 
-func ExpandString(name, content string, data map[string]any) (string, error) {
+func (e *Engine) ExpandString(name, content string, data map[string]any) (string, error) {
 	if len(content) == 0 {
 		return content, nil
 	}
 
-	t, err := template.Must(baseTemplate.Clone()).
-		Parse(content)
+	t, err := e.GetTemplate().Parse(content)
 
 	if err != nil {
 		return "", err
@@ -155,3 +286,10 @@ func resolvePointers(data any) any {
 		return val
 	}
 }
+
+// Whether `err` happened while executing a template, as opposed to
+// while parsing it.
+func IsExecError(err error) bool {
+	var execErr template.ExecError
+	return errors.As(err, &execErr)
+}
@@ -1,6 +1,9 @@
+import hashlib
+import json
 import os
 import shutil
 import subprocess
+import tempfile
 import unittest  # Bad name
 from typing import Callable, Type
 from types import TracebackType
@@ -47,6 +50,303 @@ class TestCli(unittest.TestCase):
 			result = file.read()
 			self.assertEqual(result, '`something`\n')
 
+	@run_in_dir('simple')
+	def test_dry_run(self):
+		if os.path.exists('result.txt'):
+			os.remove('result.txt')
+
+		process = subprocess.run(
+			['qveen', '--dry-run', 'params.toml'],
+			check=True,
+			capture_output=True,
+			encoding='utf-8')
+
+		self.assertEqual(
+			process.stdout,
+			'0 create template.tmpl -> result.txt (12 bytes)\n')
+		self.assertFalse(os.path.exists('result.txt'))
+
+	@run_in_dir('directory')
+	def test_directory(self):
+		shutil.rmtree('result', ignore_errors=True)
+
+		subprocess.run(
+			['qveen', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		with open('result/users/handler.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), '`users`\n')
+
+		with open('result/users/verbatim.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), '`{{.name}}`\n')
+
+		self.assertFalse(os.path.exists('result/ignored.txt'))
+
+	@run_in_dir('skip')
+	def test_skip_keeps_manifest(self):
+		for path in ['result.txt', 'manifest.json']:
+			if os.path.exists(path):
+				os.remove(path)
+
+		subprocess.run(
+			['qveen', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		# Renders something else, which must not be taken as written.
+		subprocess.run(
+			['qveen', 'params.toml', 'second.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		with open('result.txt', 'rb') as file:
+			content = file.read()
+			self.assertEqual(content, b'`first`\n')
+
+		with open('manifest.json', encoding='utf-8') as file:
+			manifest = json.load(file)
+			self.assertEqual(
+				manifest['files']['result.txt']['sha256'],
+				hashlib.sha256(content).hexdigest())
+
+	@run_in_dir('transaction')
+	def test_failed_write_rolls_back(self):
+		shutil.rmtree('created', ignore_errors=True)
+
+		with open('kept.txt', 'w', encoding='utf-8') as file:
+			file.write('old\n')
+
+		process = subprocess.run(
+			['qveen', 'params.toml'],
+			stderr=subprocess.DEVNULL)
+
+		self.assertEqual(process.returncode, 7)
+		self.assertFalse(os.path.exists('created'))
+
+		with open('kept.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'old\n')
+
+		self.assertEqual(
+			[name for name in os.listdir('.') if '.qveen-' in name], [])
+
+	@run_in_dir('merge')
+	def test_merge_precedence(self):
+		if os.path.exists('result.txt'):
+			os.remove('result.txt')
+
+		subprocess.run(
+			['qveen', 'base.toml', 'override.toml'],
+			check=True,
+			stdout=subprocess.DEVNULL)
+
+		with open('result.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'override base localhost:6543\n')
+
+	@run_in_dir('clean')
+	def test_clean_removes_only_stale_files(self):
+		shutil.rmtree('generated', ignore_errors=True)
+
+		if os.path.exists('manifest.json'):
+			os.remove('manifest.json')
+
+		# The same parameter file, before and after losing a pair.
+		shutil.copyfile('two.toml', 'params.toml')
+
+		subprocess.run(
+			['qveen', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		subprocess.run(
+			['qveen', 'other.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		with open('generated/manual.txt', 'w', encoding='utf-8') as file:
+			file.write('manual\n')
+
+		shutil.copyfile('one.toml', 'params.toml')
+
+		subprocess.run(
+			['qveen', 'clean', '-y', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		self.assertEqual(
+			sorted(os.listdir('generated')),
+			['kept.txt', 'manual.txt', 'other.txt'])
+
+	@run_in_dir('inject')
+	def test_inject_is_idempotent(self):
+		shutil.copyfile('original.txt', 'routes.txt')
+
+		for _ in range(2):
+			subprocess.run(
+				['qveen', 'params.toml'],
+				check=True,
+				stderr=subprocess.DEVNULL)
+
+		with open('routes.txt', encoding='utf-8') as file:
+			self.assertEqual(
+				file.read(),
+				'package main\n\nfunc routes() {\n\tget("/users")\n}\n')
+
+	@run_in_dir('hooks')
+	def test_failed_hook(self):
+		for path in ['result.txt', 'after.txt']:
+			if os.path.exists(path):
+				os.remove(path)
+
+		process = subprocess.run(
+			['qveen', 'params.toml'],
+			capture_output=True,
+			encoding='utf-8')
+
+		self.assertEqual(process.returncode, 9)
+		self.assertIn('exit 3', process.stderr)
+		self.assertTrue(os.path.exists('result.txt'))
+		self.assertFalse(os.path.exists('after.txt'))
+
+		os.remove('result.txt')
+
+		subprocess.run(
+			['qveen', '--no-hooks', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+	@run_in_dir('pinned')
+	def test_pin_mismatch(self):
+		if os.path.exists('result.txt'):
+			os.remove('result.txt')
+
+		process = subprocess.run(
+			['qveen', 'wrong.toml'],
+			stderr=subprocess.DEVNULL)
+
+		self.assertEqual(process.returncode, 7)
+		self.assertFalse(os.path.exists('result.txt'))
+
+		subprocess.run(
+			['qveen', 'good.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		with open('result.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'pinned\n')
+
+	@run_in_dir('pinned')
+	def test_offline_cold_cache(self):
+		with tempfile.TemporaryDirectory() as cache:
+			process = subprocess.run(
+				['qveen', '--offline', 'offline.toml'],
+				env={**os.environ, 'QVEEN_CACHE_DIR': cache},
+				capture_output=True,
+				encoding='utf-8')
+
+		self.assertEqual(process.returncode, 7)
+		self.assertIn('cannot be fetched offline', process.stderr)
+		self.assertFalse(os.path.exists('offline.txt'))
+
+	@run_in_dir('jobs')
+	def test_jobs_keep_order(self):
+		outputs = []
+
+		for jobs in ['1', '4']:
+			shutil.rmtree('result', ignore_errors=True)
+
+			process = subprocess.run(
+				['qveen', '-j', jobs, 'params.toml'],
+				check=True,
+				capture_output=True,
+				encoding='utf-8')
+
+			files = {}
+
+			for name in sorted(os.listdir('result')):
+				with open(os.path.join('result', name), encoding='utf-8') as file:
+					files[name] = file.read()
+
+			outputs.append((process.stderr, files))
+
+		self.assertEqual(outputs[0], outputs[1])
+		self.assertEqual(len(outputs[1][1]), 8)
+		self.assertEqual(outputs[1][1]['h.txt'], '7 h\n')
+
+	@run_in_dir('partials')
+	def test_partials(self):
+		if os.path.exists('users.txt'):
+			os.remove('users.txt')
+
+		# `lib/greet.tmpl` is listed twice, but only loaded once.
+		subprocess.run(
+			['qveen', '-P', 'extra.tmpl', 'params.toml'],
+			check=True,
+			stderr=subprocess.DEVNULL)
+
+		with open('users.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'Hello, users and more\n')
+
+		os.remove('users.txt')
+
+		process = subprocess.run(
+			['qveen', '-P', 'extra.tmpl', '-P', 'duplicate.tmpl', 'params.toml'],
+			capture_output=True,
+			encoding='utf-8')
+
+		self.assertEqual(process.returncode, 5)
+		self.assertIn("'lib/greet.tmpl'", process.stderr)
+		self.assertIn("'duplicate.tmpl'", process.stderr)
+		self.assertFalse(os.path.exists('users.txt'))
+
+	@run_in_dir('registry')
+	def test_registry(self):
+		shutil.rmtree('sub', ignore_errors=True)
+		os.makedirs('sub')
+
+		if os.path.exists('greeting.txt'):
+			os.remove('greeting.txt')
+
+		process = subprocess.run(
+			['qveen', 'list'],
+			check=True,
+			capture_output=True,
+			encoding='utf-8',
+			cwd='sub')
+
+		self.assertEqual(
+			process.stdout,
+			'farewell  Says goodbye\ngreeting  Says hello\n')
+
+		# Generators run from the directory of the config, but given paths
+		# are relative to where they are run from.
+		subprocess.run(
+			['qveen', 'run', 'greeting'],
+			check=True,
+			stderr=subprocess.DEVNULL,
+			cwd='sub')
+
+		subprocess.run(
+			['qveen', 'run', 'farewell', '-o', 'here.txt'],
+			check=True,
+			stderr=subprocess.DEVNULL,
+			cwd='sub')
+
+		with open('greeting.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'Hello\n')
+
+		with open('sub/here.txt', encoding='utf-8') as file:
+			self.assertEqual(file.read(), 'Goodbye\n')
+
+		process = subprocess.run(
+			['qveen', 'run', 'missing'],
+			capture_output=True,
+			encoding='utf-8',
+			cwd='sub')
+
+		self.assertNotEqual(process.returncode, 0)
+		self.assertIn('farewell, greeting', process.stderr)
+
 
 if __name__ == '__main__':
 	unittest.main()
@@ -0,0 +1,305 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffOpKind byte
+
+const (
+	diffEqual  diffOpKind = ' '
+	diffDelete diffOpKind = '-'
+	diffInsert diffOpKind = '+'
+)
+
+type diffOp struct {
+	Kind diffOpKind
+	Line string
+
+	// Positions in `a` and `b` before the operation is applied.
+	APos int
+	BPos int
+}
+
+// Splits keeping the line terminators, so that we can tell whether the
+// last line had one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Past this many inserted and deleted lines, diffs are not worth the
+// time and memory they take, which grow with its square.
+const maxDiffEdits = 2000
+
+// Myers' algorithm. See "An O(ND) Difference Algorithm and Its
+// Variations". The common prefix and suffix are left out of the search.
+// Not ok if the files are too different to bother.
+func diffLines(a, b []string) ([]diffOp, bool) {
+	prefix := 0
+
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{diffEqual, a[i], i, i})
+	}
+
+	middle, ok := diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+
+	if !ok {
+		return nil, false
+	}
+
+	for _, op := range middle {
+		op.APos += prefix
+		op.BPos += prefix
+		ops = append(ops, op)
+	}
+
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, diffOp{diffEqual, a[len(a)-i], len(a) - i, len(b) - i})
+	}
+
+	return ops, true
+}
+
+func diffMiddle(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+
+	if max == 0 {
+		return nil, true
+	}
+
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	// For each step `d`, the furthest `x` of diagonals `-d` to `d`
+	// before it, which is all the backtracking needs.
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return nil, false
+		}
+
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back through the trace to recover the edits.
+	var ops []diffOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		var prevX int
+
+		// At `d` = 0 there is no previous step, and we start from the
+		// top left.
+		if d > 0 {
+			prevX = v[d+prevK]
+		}
+
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{diffEqual, a[x-1], x - 1, y - 1})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{diffInsert, b[y-1], x, y - 1})
+			} else {
+				ops = append(ops, diffOp{diffDelete, a[x-1], x - 1, y})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops, true
+}
+
+// Returns a unified diff going from `a` to `b`, with `context` lines
+// around each change. Empty if both are equal. Only says that they
+// differ if they differ too much.
+func UnifiedDiff(aName, bName, a, b string, context int) string {
+	ops, ok := diffLines(splitLines(a), splitLines(b))
+
+	if !ok {
+		return fmt.Sprintf("Files %s and %s differ\n", aName, bName)
+	}
+
+	var builder strings.Builder
+	wroteHeader := false
+
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+
+		// Found a change. Extend the hunk while the next change is close
+		// enough for their contexts to touch.
+		start := max(i-context, 0)
+		end := i
+
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != diffEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+
+		end = min(end+context, len(ops))
+
+		if !wroteHeader {
+			fmt.Fprintf(&builder, "--- %s\n+++ %s\n", aName, bName)
+			wroteHeader = true
+		}
+
+		writeHunk(&builder, ops[start:end])
+		i = end
+	}
+
+	return builder.String()
+}
+
+func writeHunk(builder *strings.Builder, ops []diffOp) {
+	aStart, bStart := ops[0].APos, ops[0].BPos
+	aCount, bCount := 0, 0
+
+	for _, op := range ops {
+		if op.Kind != diffInsert {
+			aCount++
+		}
+
+		if op.Kind != diffDelete {
+			bCount++
+		}
+	}
+
+	// Ranges are 1-based, except empty ones, which refer to the line
+	// right before them.
+	if aCount > 0 {
+		aStart++
+	}
+
+	if bCount > 0 {
+		bStart++
+	}
+
+	fmt.Fprintf(builder, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+
+	for _, op := range ops {
+		builder.WriteByte(byte(op.Kind))
+		builder.WriteString(op.Line)
+
+		if !strings.HasSuffix(op.Line, "\n") {
+			builder.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// Adds terminal colors to the output of `UnifiedDiff`.
+func ColorDiff(diff string) string {
+	const (
+		reset = "\x1b[0m"
+		bold  = "\x1b[1m"
+		red   = "\x1b[31m"
+		green = "\x1b[32m"
+		cyan  = "\x1b[36m"
+	)
+
+	var builder strings.Builder
+
+	for _, line := range splitLines(diff) {
+		text := strings.TrimSuffix(line, "\n")
+		color := ""
+
+		switch {
+		case strings.HasPrefix(text, "--- "), strings.HasPrefix(text, "+++ "):
+			color = bold
+		case strings.HasPrefix(text, "@@"):
+			color = cyan
+		case strings.HasPrefix(text, "-"):
+			color = red
+		case strings.HasPrefix(text, "+"):
+			color = green
+		}
+
+		if color == "" {
+			builder.WriteString(line)
+			continue
+		}
+
+		builder.WriteString(color)
+		builder.WriteString(text)
+		builder.WriteString(reset)
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
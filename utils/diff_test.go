@@ -0,0 +1,77 @@
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"empty", "", "", ""},
+		{"identical", "a\nb\n", "a\nb\n", ""},
+		{
+			"insert only",
+			"",
+			"a\nb\n",
+			"--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			"delete only",
+			"a\nb\n",
+			"",
+			"--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"no trailing newline",
+			"a\nb",
+			"a\nc",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"trailing newline added",
+			"a",
+			"a\n",
+			"--- a\n+++ b\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		{
+			"contexts touching",
+			"1\n2\n3\n4\n5\n6\n7\n",
+			"1\nX\n3\n4\nY\n6\n7\n",
+			"--- a\n+++ b\n@@ -1,6 +1,6 @@\n 1\n-2\n+X\n 3\n 4\n-5\n+Y\n 6\n",
+		},
+		{
+			"contexts apart",
+			"1\n2\n3\n4\n5\n6\n7\n",
+			"1\nX\n3\n4\n5\nY\n7\n",
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+X\n 3\n@@ -5,3 +5,3 @@\n 5\n-6\n+Y\n 7\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := UnifiedDiff("a", "b", test.a, test.b, 1)
+
+			if actual != test.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffTooDifferent(t *testing.T) {
+	var a, b []byte
+
+	for i := 0; i <= maxDiffEdits; i++ {
+		a = append(a, "a\n"...)
+		b = append(b, "b\n"...)
+	}
+
+	actual := UnifiedDiff("a", "b", string(a), string(b), 3)
+	expected := "Files a and b differ\n"
+
+	if actual != expected {
+		t.Errorf("Expected %q, got %q", expected, actual)
+	}
+}
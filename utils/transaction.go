@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type stagedFile struct {
+	Path     string
+	TempPath string
+	Existed  bool
+
+	// Copy of the previous contents, made right before committing.
+	BackupPath string
+	Committed  bool
+}
+
+// Writes a set of files all at once, or not at all.
+//
+// Contents are first written to temporary files next to their
+// destinations, which are then renamed over them on `Commit`. If
+// anything fails, or if `Rollback` is called, the tree is left as it
+// was before.
+type Transaction struct {
+	files []stagedFile
+
+	// Directories we had to create, parents first.
+	dirs []string
+}
+
+// New files get 0666 minus the umask, and existing ones keep their
+// mode.
+func (t *Transaction) Stage(path string, content []byte) error {
+	return t.StageMode(path, content, 0)
+}
+
+// Like `Stage`, but the file will have exactly `mode`, unless it is 0.
+func (t *Transaction) StageMode(path string, content []byte, mode fs.FileMode) error {
+	dir := filepath.Dir(path)
+	err := t.mkdirAll(dir)
+
+	if err != nil {
+		return err
+	}
+
+	var perm fs.FileMode = 0666
+	stat, err := os.Stat(path)
+	existed := err == nil
+
+	if existed {
+		if stat.IsDir() {
+			return fmt.Errorf("Destination '%s' already exists and is a directory", path)
+		}
+
+		perm = stat.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	temp, err := createTemp(dir, filepath.Base(path), perm)
+
+	if err != nil {
+		return err
+	}
+
+	// Register before writing so that it gets cleaned up on failure.
+	t.files = append(t.files, stagedFile{
+		Path:     path,
+		TempPath: temp.Name(),
+		Existed:  existed,
+	})
+
+	_, err = temp.Write(content)
+
+	if err != nil {
+		temp.Close()
+		return err
+	}
+
+	err = temp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	if mode != 0 {
+		return os.Chmod(temp.Name(), mode)
+	}
+
+	if existed {
+		// Keep the mode of the file being replaced, regardless of umask.
+		return os.Chmod(temp.Name(), perm)
+	}
+
+	return nil
+}
+
+func (t *Transaction) Commit() error {
+	for i := range t.files {
+		f := &t.files[i]
+
+		if f.Existed {
+			backup, err := backupFile(f.Path)
+
+			if err != nil {
+				t.Rollback()
+				return err
+			}
+
+			f.BackupPath = backup
+		}
+
+		err := os.Rename(f.TempPath, f.Path)
+
+		if err != nil {
+			t.Rollback()
+			return err
+		}
+
+		f.Committed = true
+	}
+
+	for _, f := range t.files {
+		if f.BackupPath != "" {
+			os.Remove(f.BackupPath)
+		}
+	}
+
+	t.files = nil
+	t.dirs = nil
+	return nil
+}
+
+// Undoes everything done so far. Best effort.
+func (t *Transaction) Rollback() {
+	for i := len(t.files) - 1; i >= 0; i-- {
+		f := t.files[i]
+
+		if !f.Committed {
+			os.Remove(f.TempPath)
+
+			if f.BackupPath != "" {
+				os.Remove(f.BackupPath)
+			}
+
+			continue
+		}
+
+		if f.BackupPath != "" {
+			os.Rename(f.BackupPath, f.Path)
+		} else {
+			os.Remove(f.Path)
+		}
+	}
+
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		// Fails if not empty, which is what we want.
+		os.Remove(t.dirs[i])
+	}
+
+	t.files = nil
+	t.dirs = nil
+}
+
+// Like `os.MkdirAll`, but remembers which directories were created.
+func (t *Transaction) mkdirAll(dir string) error {
+	var missing []string
+
+	for {
+		_, err := os.Stat(dir)
+
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		missing = append(missing, dir)
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := os.Mkdir(missing[i], 0777)
+
+		if err != nil {
+			return err
+		}
+
+		t.dirs = append(t.dirs, missing[i])
+	}
+
+	return nil
+}
+
+// Unlike `os.CreateTemp`, lets the umask apply to `perm`, so that new
+// files end up with the same mode as with `os.Create`.
+func createTemp(dir, name string, perm fs.FileMode) (*os.File, error) {
+	for {
+		random := make([]byte, 6)
+		_, err := rand.Read(random)
+
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(
+			dir,
+			"."+name+".qveen-"+hex.EncodeToString(random),
+		)
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		return file, err
+	}
+}
+
+func backupFile(path string) (string, error) {
+	source, err := os.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer source.Close()
+
+	stat, err := source.Stat()
+
+	if err != nil {
+		return "", err
+	}
+
+	backup, err := createTemp(filepath.Dir(path), filepath.Base(path), 0600)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(backup, source)
+
+	if err != nil {
+		backup.Close()
+		os.Remove(backup.Name())
+		return "", err
+	}
+
+	err = backup.Close()
+
+	if err == nil {
+		// So that it is restored with the right mode on rollback.
+		err = os.Chmod(backup.Name(), stat.Mode().Perm())
+	}
+
+	if err != nil {
+		os.Remove(backup.Name())
+		return "", err
+	}
+
+	return backup.Name(), nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/veigaribo/qveen/qveen"
+)
+
+const (
+	watchPollInterval = 300 * time.Millisecond
+
+	// How long things must stay still after a change before rendering
+	// again, so that a burst of writes triggers only one render.
+	watchDebounce = 200 * time.Millisecond
+)
+
+// Renders, then renders again every time the parameter files or the
+// templates change, until `ctx` is done. Errors are printed instead of
+// ending the process, unless the first render is aborted. Files written
+// by an earlier render are overwritten without asking, unless edited
+// since.
+func Watch(ctx context.Context, opts qveen.Options, paramsPaths []string, reportPath string) {
+	var session qveen.RenderSession
+	opts.Session = &session
+	renderer := qveen.NewRenderer(opts)
+
+	err := renderWatched(ctx, renderer, paramsPaths, reportPath)
+
+	// Whoever said no is not going to want it again on every change.
+	if isAborted(err) {
+		os.Exit(qveen.ExitCode(err))
+	}
+
+	sources := session.Sources
+	snapshot := takeSnapshot(sources)
+
+	fmt.Fprintln(os.Stderr, "Watching for changes...")
+
+	for sleep(ctx, watchPollInterval) {
+		current := takeSnapshot(sources)
+
+		if maps.Equal(current, snapshot) {
+			continue
+		}
+
+		for sleep(ctx, watchDebounce) {
+			next := takeSnapshot(sources)
+
+			if maps.Equal(next, current) {
+				break
+			}
+
+			current = next
+		}
+
+		fmt.Fprintln(os.Stderr, "Change detected, rendering again.")
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		session.Sources = nil
+		renderWatched(ctx, renderer, paramsPaths, reportPath)
+
+		// If it failed too early to find out, keep watching the same.
+		if len(session.Sources) > 0 {
+			sources = session.Sources
+		}
+
+		snapshot = takeSnapshot(sources)
+	}
+}
+
+// Prints the error, if any, and returns it.
+func renderWatched(
+	ctx context.Context,
+	renderer *qveen.Renderer,
+	paramsPaths []string,
+	reportPath string,
+) error {
+	err := render(ctx, renderer, paramsPaths, reportPath)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return err
+}
+
+// Waits for `d`, unless `ctx` is done first. Whether it is not done.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func isAborted(err error) bool {
+	var renderErr qveen.RenderError
+	return errors.As(err, &renderErr) && renderErr.Kind == qveen.AbortedErrorKind
+}
+
+type fileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// State of every file in `paths`, descending into directories. Missing
+// files are simply left out, so that their creation shows up as a
+// change.
+func takeSnapshot(paths []string) map[string]fileState {
+	snapshot := make(map[string]fileState)
+
+	for _, path := range paths {
+		filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			info, err := d.Info()
+
+			if err != nil {
+				return nil
+			}
+
+			snapshot[p] = fileState{
+				ModTime: info.ModTime(),
+				Size:    info.Size(),
+			}
+
+			return nil
+		})
+	}
+
+	return snapshot
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/veigaribo/qveen/qveen"
+)
+
+// Waits for the file at `path` to contain `expected`, failing the test
+// if it takes too long.
+func waitForFile(t *testing.T, path, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		content, err := os.ReadFile(path)
+
+		if err == nil && string(content) == expected {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected '%s' to contain %q, got %q (%v)", path, expected, content, err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestWatchRendersAgainOnChange(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	err := os.WriteFile("params.toml", []byte("[meta]\ntemplate = \"template.tmpl\"\noutput = \"result.txt\"\n"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile("template.tmpl", []byte("first\n"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		Watch(ctx, qveen.Options{
+			Stdout: io.Discard,
+			Stderr: io.Discard,
+		}, []string{"params.toml"}, "")
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForFile(t, "result.txt", "first\n")
+
+	// Written by the earlier render, so it is overwritten without
+	// asking.
+	err = os.WriteFile("template.tmpl", []byte("second one\n"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForFile(t, "result.txt", "second one\n")
+}